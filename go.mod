@@ -1,3 +1,3 @@
 module github.com/josephpaul0/tdu
 
-go 1.10
+go 1.24
@@ -25,16 +25,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
+	"unicode/utf8"
 )
 
 const (
@@ -46,8 +53,29 @@ const (
 	dft_MAXSTREAMS    = 0
 	dft_MAXDEVICES    = 0
 	dft_MAXBIGFILES   = 8
+	dft_MAXLOCKED     = 8
+	dft_MAXXATTRS     = 0
+	dft_MAXITEMDIRS   = 0
+	dft_MAXSPARSE     = 0
+	dft_DEPTH         = 1 // levels shown
+	dft_DEPTHITEMS    = 5 // entries shown per sub-directory
+	dft_PRECISION     = 1
+	dft_COLORHIGH     = 25.0 // % of total disk usage
+	dft_COLORMID      = 10.0
+	dft_RETRIES       = 2
+	dft_RETRYDELAY    = 100 // ms, doubled after each attempt
+	dft_STATTIMEOUT   = 60 * time.Second
 	cst_ENDPROGRESS   = "###"
-	cst_PROGRESSBEAT  = 80 // ms
+	cst_PROGRESSBEAT  = 80   // ms
+	cst_PROGRESSWIDTH = 79   // width of the progress line, when unknown
+	cst_WATCHBEAT     = 2000 // ms, between refreshes of --watch
+	cst_TABLEBAR      = 20   // max width of the bars of the main table
+)
+
+const ( // Share of total disk usage, for row colors
+	share_LOW = iota
+	share_MID
+	share_HIGH
 )
 
 type file struct { // File information for each scanned item
@@ -58,8 +86,10 @@ type file struct { // File information for each scanned item
 	isDir      bool
 	isSymlink  bool
 	isOtherFs  bool
+	isTagged   bool // on another filesystem, but included (--tag-other-fs)
 	isSpecial  bool
 	readError  bool
+	isSparse   bool // allocates far less than its apparent size
 	size       int64
 	diskUsage  int64
 	depth      int64
@@ -69,10 +99,34 @@ type file struct { // File information for each scanned item
 	inode      uint64
 	nLinks     uint64
 	deviceId   uint64
+	otherDu    int64  // disk usage located on tagged filesystems
+	otherDev   uint64 // first tagged device found under this item
+	kids       []file // largest entries, kept with --depth
 	fi         os.FileInfo
+	mtime      time.Time // last modification
+	btime      time.Time // creation, when the filesystem records it
+	duVar      float64   // variance of the estimated disk usage (--estimate)
+}
+
+type ino_key struct { // inode numbers are only unique per device
+	dev uint64
+	ino uint64
 }
 
-type ino_map map[uint64]uint16 // map of inode number and counter
+type ino_map map[ino_key]uint16 // map of inode number and counter
+
+type dev_usage struct { // Usage of one device when crossing filesystems
+	dev       uint64
+	items     int64
+	diskUsage int64
+}
+
+/* Told by scan() of what it accounts, so that --watch follows the same
+ * items with the same sizes, see tdu_watch.go */
+type scan_watcher interface {
+	watchDir(path string)           // a directory whose entries were read
+	watchItem(path string, f *file) // an item, with its own usage only
+}
 
 type s_scan struct { // Global variables
 	nErrors       int64    // number of Lstat errors
@@ -87,23 +141,28 @@ type s_scan struct { // Global variables
 	nPipes        int64    // number of named pipes
 	nCharDevices  int64    // number of character devices
 	nBlockDevices int64    // number of block devices
+	nExcluded     int64    // number of skipped items
 	reachedDepth  int64    // maximum directory depth reached
 	maxPathLen    int64    // maximum directory path length
 	maxFNameLen   int64    // maximum filename length
 	currentDevice uint64   // device number of current partition
+	parentDevice  uint64   // device number of the directory being scanned
 	refreshDelay  int64    // delay between progress bar updates
 	maxWidth      int      // display width (tty columns)
 	maxNameLen    int      // max filename length for depth = 1
 	maxShownLines int      // number of depth 1 items to display
 	maxBigFiles   int      // number of biggest files to display
+	maxItemDirs   int      // number of directories with most entries to display
 	maxEmptyDirs  int      // number of empty directories to display
 	maxDenied     int      // number of denied directories to display
 	maxErrors     int      // number of 'lstat' errors to display
 	maxStreams    int      // number of sockets and named pipes to display
 	maxDevices    int      // number of character and block devices to display
 	wsl           bool     // Windows Subsystem for Linux
+	wsl2          bool     // WSL2, a real Linux kernel in a virtual machine
 	partinfo      bool     // found info about partition
 	foundBoundary bool     // found other filesystems
+	tagOtherFs    bool     // descend into other filesystems and tag them
 	showMax       bool     // show deepest and longest paths
 	export        bool     // export result to Ncdu's JSON format
 	tty           bool     // stdout is on a TTY
@@ -111,6 +170,7 @@ type s_scan struct { // Global variables
 	consoleMax    bool     // maximize size of console window (on Windows only)
 	exportPath    string   // path to exported file
 	exportFile    *os.File // exported file
+	exportSep     bool     // separator pending before next exported item
 	deepestPath   string   // deepest subdirectory reached
 	longestPath   string   // longest directory path
 	longestFName  string   // longest filename
@@ -121,15 +181,148 @@ type s_scan struct { // Global variables
 	pathSeparator string   // os.PathSeparator as string
 	inodes        ino_map  // inode number to file path
 	bigfiles      []file
+	itemdirs      []dir_count // directories with the most entries
 	emptydirs     []string
 	denieddirs    []string
 	errors        []error
 	streams       []string  // sockets and named pipes
 	devices       []string  // character and block devices
+	otherfs       []file    // mountpoints of other filesystems
 	start         time.Time // time at process start
 	msg           chan string
 	done          chan bool
-	sys           interface{} // OS functions
+	resize        chan bool                // the terminal was resized
+	sys           interface{}              // OS functions
+	devusage      map[uint64]*dev_usage    // usage per device (--tag-other-fs)
+	usageLog      string                   // history of partition usage samples
+	jsonl         bool                     // stream scanned entries as JSON Lines
+	out           io.Writer                // human readable report
+	con           io.Writer                // console, for progress and colors
+	report        *os.File                 // copy of the report (--output)
+	plain         bool                     // raw bytes and full paths, no padding
+	plainSet      bool                     // --plain given on the command line
+	root          string                   // scanned directory
+	precision     int                      // decimals of human readable sizes
+	colors        bool                     // color table rows by share of total
+	colorHigh     float64                  // % of total shown in red
+	colorMid      float64                  // % of total shown in yellow
+	baseline      map[string]int64         // disk usage of depth 1 items in baseline
+	dual          bool                     // show disk usage and apparent size
+	apparent      bool                     // show and sort by apparent size
+	sortBy        string                   // order of the main table (--sort)
+	reverse       bool                     // reversed order of the main table
+	maxDepth      int64                    // levels shown (--depth)
+	reporter      reporter                 // machine readable report (--format)
+	rep           io.Writer                // output of the reporter
+	enableDelete  bool                     // offer to delete the biggest files
+	watch         bool                     // keep totals updated after the scan
+	savePath      string                   // snapshot written after the scan (--save)
+	loadPath      string                   // snapshot shown instead of scanning (--load)
+	importPath    string                   // Ncdu export shown instead of scanning
+	archivePath   string                   // zip or tar file shown instead of scanning
+	snapRoot      *node                    // tree of the scan, for --save
+	snapDirs      []*node                  // directories being scanned, for --save
+	subvolumes    bool                     // btrfs subvolumes are boundaries
+	snapshots     bool                     // scan snapper/timeshift snapshots
+	system        bool                     // whole-root scan preset
+	allMounts     bool                     // scan every local mountpoint
+	compare       bool                     // tdu compare DIR1 DIR2
+	manifestPath  string                   // hash manifest file (--manifest)
+	manifestMax   int64                    // files above this size are not hashed
+	manifest      []manifest_entry         // regular files to hash
+	maxItems      int64                    // stop scanning after n items
+	maxBytes      int64                    // stop scanning after n bytes
+	timeout       time.Duration            // stop scanning after this duration
+	statTimeout   time.Duration            // a stat or readdir is abandoned after it
+	estimate      float64                  // fraction of subdirectories scanned
+	estDirs       int64                    // subdirectories seen with --estimate
+	estScanned    int64                    // and those scanned
+	fs            scan_fs                  // system calls of the scan
+	watcher       scan_watcher             // nil unless --watch
+	deterministic bool                     // same output for the same tree
+	hung          []string                 // paths that did not answer in time
+	status        chan os.Signal           // SIGUSR1, answered by scan()
+	maxHandles    int64                    // depth of the last directory handle kept open
+	nBytes        int64                    // disk usage scanned so far
+	truncated     bool                     // a budget was reached
+	retries       int                      // attempts after a transient error
+	retryDelay    int                      // ms before the first retry
+	nRetries      int64                    // number of retries done
+	nLocked       int64                    // files in use by another process
+	maxLocked     int                      // max number of locked files shown
+	locked        []string                 // locked files
+	xattrExclude  bool                     // honor exclusion extended attributes
+	maxXattrs     int                      // max number of xattr sizes shown
+	xattrs        []xattr_usage            // biggest extended attributes
+	xattrTotal    int64                    // size of all extended attributes
+	years         map[int]int64            // disk usage per modification year
+	exts          map[string]*ext_usage    // disk usage per file extension
+	categories    map[string]*ext_usage    // disk usage per category of files
+	maxExts       int                      // number of extensions shown (--by-ext)
+	owners        map[uint32]*owner_usage  // disk usage per user (--by-owner)
+	ages          []int64                  // disk usage per age bucket (--age)
+	coldfiles     []file                   // largest files older than a year
+	groups        map[uint32]*owner_usage  // disk usage per group
+	excludes      patterns                 // --exclude shell patterns
+	snapshotdirs  []string                 // skipped snapshot directories
+	olderThan     time.Duration            // only list files older than this
+	newerThan     time.Duration            // only list files newer than this
+	nSparse       int64                    // number of sparse files
+	maxSparse     int                      // number of sparse files shown
+	sparsefiles   []file                   // sparse files with the most holes
+	si            bool                     // powers of 1000 instead of 1024
+	unit          int64                    // sizes shown in this unit (--bytes, -B)
+	unitLabel     string                   // unit as given on the command line
+	duOutput      bool                     // print du -skx compatible lines (--du)
+	bars          bool                     // bar column in the main table
+	scanStart     time.Time                // start of the current scan
+	expectItems   int64                    // used inodes, when the whole fs is scanned
+	curDir        chan string              // directory being scanned, for the progress
+	scanning      string                   // last directory received from curDir
+	progressCols  int                      // width of the progress line
+	quiet         bool                     // only print the results (-q)
+	ctx           context.Context          // cancelled by Ctrl+C during a scan
+	interrupted   bool                     // the scan was cancelled
+	failed        bool                     // a directory could not be scanned, exit status 1
+	shared        map[string]*extent_usage // shared extents per depth 1 item
+	compression   bool                     // compression ratio of each item
+	mountList     bool                     // only list the mounted filesystems
+	ranking       *[]file                  // depth 1 items of every mountpoint
+	includePseudo bool                     // cross into proc, sysfs, tmpfs...
+	dupMounts     map[string]string        // bind mounts and overlays to skip
+	dupdirs       []string                 // skipped duplicate mounts
+	dockerReport  bool                     // usage per image, container, volume
+	docker        *container_store         // storage found in the scanned directory
+	logReport     bool                     // journal and log files report
+	logs          *log_usage               // usage of /var/log, if scanned
+	reclaimReport bool                     // caches and trash that could be emptied
+	reclaim       []*reclaim_place         // reclaimable places in the scanned directory
+	excludeCaches bool                     // show directories tagged with CACHEDIR.TAG
+	skipCaches    bool                     // skip them
+	cachedirs     []file                   // tagged directories
+	cacheDepth    int64                    // depth of the tagged directory being scanned
+	git           *git_state               // items ignored by git, if shown or skipped
+	repos         map[string]*git_repo     // git repositories, with --git-repos
+	prune         *prune_state             // build directories, with --prune-preset
+	inodeReport   bool                     // inodes used by each item
+	inodeTotal    uint64                   // size of the inode table, if known
+	audit         *audit_report            // security audit, with --audit
+	slack         map[string]*slack_usage  // block rounding per depth 1 item
+	maxEmptyFiles int                      // number of zero-byte files to display
+	nEmptyFile    int64                    // number of zero-byte files
+	emptyfiles    []string
+	tinydirs      []tiny_dir // directories of tiny files
+	dotPath       string     // Graphviz graph of the tree (--dot)
+	foldedPath    string     // folded stacks of the tree (--folded)
+	history       bool       // summary appended to the history file
+	historyCmd    bool       // tdu history [DIR]
+	historyRuns   int        // runs shown by tdu history
+	progressJSON  io.Writer  // progress events as JSON Lines
+	partSize      int64      // size of the partition, if known
+	partAvail     int64      // space available on the partition
+	partFree      int64      // free space, with the blocks reserved to root
+	info          io.Writer  // title and partition banner
+	progress      bool       // the progress line is shown on sc.con
 }
 
 func detectOS(sc *s_scan) {
@@ -146,12 +339,18 @@ func detectOS(sc *s_scan) {
 	if strings.Contains(s, "Microsoft") {
 		sc.wsl = true
 		sc.os = "WSL"
+	} else if strings.Contains(s, "microsoft") { // microsoft-standard-WSL2
+		sc.wsl2 = true
+		sc.os = "WSL2"
 	}
 }
 
 func getConsoleWidth(sc *s_scan) {
 	sc.maxWidth = 80
 	w := getTtyWidth(sc)
+	if sc.deterministic {
+		w = sc.maxWidth
+	}
 	if w >= 72 {
 		if w <= 120 {
 			sc.maxWidth = w
@@ -165,51 +364,220 @@ func getConsoleWidth(sc *s_scan) {
 func newScanStruct(start time.Time, sys interface{}) *s_scan {
 	var sc s_scan
 	sc.pathSeparator = string(os.PathSeparator)
-	sc.inodes = make(map[uint64]uint16, 256)
+	sc.con = os.Stdout
+	sc.out = sc.con
+	sc.info = sc.out
+	sc.fs = os_fs{}
+	sc.maxHandles = maxDirHandles()
+	sc.inodes = make(ino_map, 256)
+	sc.devusage = make(map[uint64]*dev_usage)
 	sc.start = start
 	sc.msg = make(chan string, 32)
 	sc.done = make(chan bool)
+	sc.curDir = make(chan string, 1)
+	sc.ctx = context.Background()
+	sc.resize = make(chan bool, 1)
 	sc.refreshDelay = cst_PROGRESSBEAT
 	sc.sys = sys
 	return &sc
 }
 
+/* Fresh scan state sharing the options of sc */
+func cloneScanStruct(sc *s_scan) *s_scan {
+	s := *sc
+	s.inodes = make(ino_map, 256)
+	s.devusage = make(map[uint64]*dev_usage)
+	s.msg = make(chan string, 32)
+	s.done = make(chan bool)
+	s.curDir = make(chan string, 1)
+	resetReports(&s)
+	return &s
+}
+
+/* Empty tallies of the optional reports, so that the sections of a scan
+ * do not include the items of the scans cloned from the same settings */
+func resetReports(s *s_scan) {
+	if s.shared != nil {
+		s.shared = make(map[string]*extent_usage)
+	}
+	if s.repos != nil {
+		s.repos = make(map[string]*git_repo)
+	}
+	if s.slack != nil {
+		s.slack = make(map[string]*slack_usage)
+	}
+	if s.years != nil {
+		s.years = make(map[int]int64)
+	}
+	if s.exts != nil {
+		s.exts = make(map[string]*ext_usage)
+	}
+	if s.owners != nil {
+		s.owners = make(map[uint32]*owner_usage)
+		s.groups = make(map[uint32]*owner_usage)
+	}
+	if s.ages != nil {
+		s.ages = make([]int64, len(ageBuckets))
+		s.coldfiles = nil
+	}
+	if s.audit != nil {
+		s.audit = &audit_report{modes: make(map[string]os.FileMode),
+			orphans: make(map[uint32]*owner_usage)}
+	}
+	if s.categories != nil {
+		s.categories = make(map[string]*ext_usage)
+	}
+	if s.git != nil {
+		s.git = &git_state{skip: s.git.skip}
+	}
+	if s.prune != nil {
+		s.prune = &prune_state{names: s.prune.names, skip: s.prune.skip}
+	}
+}
+
 type szDesc []file
 
 func (a szDesc) Len() int           { return len(a) }
 func (a szDesc) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a szDesc) Less(i, j int) bool { return a[i].diskUsage > a[j].diskUsage }
 
-func fmtSzHuman(size int64) string {
+type asizeDesc []file // by apparent size
+
+func (a asizeDesc) Len() int           { return len(a) }
+func (a asizeDesc) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a asizeDesc) Less(i, j int) bool { return a[i].size > a[j].size }
+
+/* The data of a file with several links is only counted at the first
+ * one, from its device and inode numbers (its file index on Windows) */
+func countHardlink(sc *s_scan, f *file) {
+	if f.isDir || f.nLinks < 2 { // cannot be a hardlink, not worth a map entry
+		return
+	}
+	key := ino_key{f.deviceId, f.inode}
+	_, ok := sc.inodes[key]
+	if ok { // Hardlink means inode used more than once in map
+		if !f.isOtherFs { // Other FS may have a same inode number (root=2)
+			f.diskUsage = 0
+			sc.nHardlinks++
+		}
+	}
+	// Each occurrence of inode is counted
+	sc.inodes[key]++
+}
+
+func sortFiles(sc *s_scan, fi []file) {
+	var s sort.Interface = szDesc(fi)
+	if sc.apparent {
+		s = asizeDesc(fi)
+	}
+	if sc.deterministic { // items of the same size in the order of their paths
+		sort.Sort(pathAsc(fi))
+		sort.Stable(s)
+		return
+	}
+	sort.Sort(s)
+}
+
+type dir_count struct { // Number of entries of a directory (-i)
+	path    string
+	entries int64 // direct entries
+	items   int64 // entries at any depth
+}
+
+type entriesDesc []dir_count
+
+func (a entriesDesc) Len() int           { return len(a) }
+func (a entriesDesc) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a entriesDesc) Less(i, j int) bool { return a[i].entries > a[j].entries }
+
+type itemsDesc []file // by number of items
+
+func (a itemsDesc) Len() int           { return len(a) }
+func (a itemsDesc) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a itemsDesc) Less(i, j int) bool { return a[i].items > a[j].items }
+
+type nameAsc []file
+
+func (a nameAsc) Len() int           { return len(a) }
+func (a nameAsc) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a nameAsc) Less(i, j int) bool { return a[i].name < a[j].name }
+
+type pathAsc []file
+
+func (a pathAsc) Len() int           { return len(a) }
+func (a pathAsc) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a pathAsc) Less(i, j int) bool { return a[i].path < a[j].path }
+
+/* Order of the main table, chosen with --sort and --reverse */
+func sortTable(sc *s_scan, fi []file) {
+	if sc.deterministic {
+		sort.Sort(pathAsc(fi)) // not in the order of the directory
+	}
+	switch sc.sortBy {
+	case "items":
+		sort.Stable(itemsDesc(fi))
+	case "name":
+		sort.Stable(nameAsc(fi))
+	case "apparent":
+		sort.Stable(asizeDesc(fi))
+	default:
+		sortFiles(sc, fi)
+	}
+	if sc.reverse {
+		for i, j := 0, len(fi)-1; i < j; i, j = i+1, j-1 {
+			fi[i], fi[j] = fi[j], fi[i]
+		}
+	}
+}
+
+/* Size shown and sorted: disk usage, or apparent size with --apparent */
+func shownSize(sc *s_scan, du, size int64) int64 {
+	if sc.apparent {
+		return size
+	}
+	return du
+}
+
+/* Units are powers of 1024 (KiB, MiB...), or of 1000 with --si (kB, MB...) */
+func sizeUnits(si bool) (float64, []string) {
+	if si {
+		return 1000, []string{"kB", "MB", "GB", "TB", "PB"}
+	}
+	return 1024, []string{"KiB", "MiB", "GiB", "TiB", "PiB"}
+}
+
+func fmtSzHuman(size int64, precision int, si bool) string {
 	var sz = float64(size)
-	var unit string = "Kb"
-	var d float64 = 1024
-	units := []string{"Kb", "Mb", "Gb", "Tb", "Pb"}
+	base, units := sizeUnits(si)
+	var unit string = units[0]
+	var d float64 = base
 	powers := []float64{2.0, 3.0, 4.0, 5.0, 6.0}
 	for i, p := range powers {
-		c := math.Pow(1024, p-1)
+		c := math.Pow(base, p-1)
 		if sz > c*2 {
 			unit = units[i]
 			d = c
 		}
 	}
 	sz /= d
-	if unit == "Kb" {
+	if unit == units[0] {
 		return fmt.Sprintf("%d %s", int64(sz), unit)
 	} else {
-		return fmt.Sprintf("%.1f %s", sz, unit)
+		return fmt.Sprintf("%.*f %s", precision, sz, unit)
 	}
 }
 
 func fmtSz(sc *s_scan, size int64) string { // Formats size
+	if sc.unit > 0 { // --bytes or -B: rounded up like du
+		return strconv.FormatInt((size+sc.unit-1)/sc.unit, 10)
+	}
 	if sc.humanReadable {
-		return fmtSzHuman(size)
+		return fmtSzHuman(size, sc.precision, sc.si)
 	}
 	var sz = float64(size)
-	var power float64 = 2.0
-	unit := "Kb"
-	sz /= math.Pow(1024, power-1)
-	return fmt.Sprintf("%d %s", int64(sz), unit)
+	base, units := sizeUnits(sc.si)
+	sz /= base
+	return fmt.Sprintf("%d %s", int64(sz), units[0])
 }
 
 // Fallback to approximate disk usage
@@ -228,26 +596,149 @@ func avgDiskUsage(sz, bsize int64) int64 {
 	return sz
 }
 
-func fullStat(sc *s_scan, path string, depth int64) (*file, error) {
-	fi, err := os.Lstat(path)
+/* Calls fn again after a transient error, waiting longer each time */
+func withRetry(sc *s_scan, fn func() error) error {
+	delay := time.Duration(sc.retryDelay) * time.Millisecond
+	err := fn()
+	for i := 0; i < sc.retries && err != nil; i++ {
+		if !isTransientErr(err) {
+			break
+		}
+		sc.nRetries++
+		time.Sleep(delay)
+		delay *= 2
+		err = fn()
+	}
+	return err
+}
+
+var errHung = errors.New("no response, skipped")
+
+/* Runs fn in a goroutine that is abandoned after --stat-timeout, so that
+ * a dead network filesystem or automount does not freeze the scan. The
+ * goroutine stays blocked in the system call until the program exits. */
+func withDeadline(sc *s_scan, path string, fn func() (interface{}, error)) (interface{}, error) {
+	if sc.statTimeout <= 0 {
+		return fn()
+	}
+	type result struct {
+		v   interface{}
+		err error
+	}
+	c := make(chan result, 1) // the abandoned goroutine must not block on it
+	go func() {
+		v, err := fn()
+		c <- result{v, err}
+	}()
+	t := time.NewTimer(sc.statTimeout)
+	defer t.Stop()
+	done := sc.ctx.Done()
+	if path == "." { // the root is read even when already cancelled, see run()
+		done = nil
+	}
+	select {
+	case r := <-c:
+		return r.v, r.err
+	case <-t.C:
+		sc.hung = append(sc.hung, fullPath(sc, path))
+	case <-done: // Ctrl+C or --timeout
+	}
+	return nil, &os.PathError{Op: "stat", Path: fullPath(sc, path), Err: errHung}
+}
+
+func errnoOf(err error) (syscall.Errno, bool) {
+	switch e := err.(type) {
+	case *os.PathError:
+		err = e.Err
+	case *os.SyscallError:
+		err = e.Err
+	}
+	errno, ok := err.(syscall.Errno)
+	return errno, ok
+}
+
+func isTransientErr(err error) bool {
+	errno, ok := errnoOf(err)
+	return ok && isTransient(errno)
+}
+
+func isLockedErr(err error) bool {
+	errno, ok := errnoOf(err)
+	return ok && isLocked(errno)
+}
+
+/* A file in use by another process is accounted with the size found
+ * in its directory entry */
+func lockedFile(sc *s_scan, files *[]file, path string, fi os.FileInfo,
+	depth int64) *file {
+	sc.nLocked++
+	full := scanPath(sc, path)
+	f := file{path: tail(full, path), fullpath: full,
+		name: tail(full, fi.Name()), depth: depth, size: fi.Size(), isRegular: true,
+		blockSize: 4096}
+	f.diskUsage = avgDiskUsage(f.size, f.blockSize)
+	if len(sc.locked) < sc.maxLocked {
+		sc.locked = append(sc.locked, f.path)
+	}
+	if files != nil {
+		*files = append(*files, f)
+	}
+	sc.nBytes += f.diskUsage
+	if sc.watcher != nil {
+		sc.watcher.watchItem(path, &f)
+	}
+	return &f
+}
+
+/* Absolute path of a scanned item, the scanned directory being the
+ * working directory (cheaper than os.Getwd for each item) */
+func scanPath(sc *s_scan, path string) string {
+	if strings.HasSuffix(sc.root, sc.pathSeparator) { // "/" or "C:\"
+		return sc.root + path
+	}
+	return sc.root + sc.pathSeparator + path
+}
+
+/* s as the end of full, when it is, so that the path, full path and name
+ * of an entry share one string instead of three */
+func tail(full, s string) string {
+	if strings.HasSuffix(full, s) {
+		return full[len(full)-len(s):]
+	}
+	return s
+}
+
+/* Stat of path, unless it was already done when reading its directory */
+func fullStat(sc *s_scan, path string, depth int64, fi os.FileInfo) (*file, error) {
+	var err error
+	if fi == nil {
+		err = withRetry(sc, func() error {
+			v, err := withDeadline(sc, path, func() (interface{}, error) {
+				return sc.fs.lstat(path)
+			})
+			fi, _ = v.(os.FileInfo)
+			return err
+		})
+	}
 	if err != nil {
+		if isLockedErr(err) {
+			return nil, err // accounted by the caller, see lockedFile()
+		}
 		sc.nErrors++
-		if sc.maxErrors > 0 {
+		if len(sc.errors) < sc.maxErrors {
 			sc.errors = append(sc.errors, err)
 		}
 		// fmt.Println(err)
 		return nil, err
 	}
 	sc.nItems++
-	wd, _ := os.Getwd()
-	var fullPath string
-	if wd == "/" {
-		fullPath = wd + path
-	} else {
-		fullPath = wd + sc.pathSeparator + path
+	fullPath := scanPath(sc, path)
+	f := file{path: tail(fullPath, path), fullpath: fullPath,
+		name: tail(fullPath, fi.Name()), depth: depth, size: fi.Size(), isDir: fi.IsDir(),
+		blockSize: 4096, fi: fi, mtime: fi.ModTime()}
+	if b, ok := fi.(interface{ birthTime() time.Time }); ok {
+		f.btime = b.birthTime() // see statx_info
 	}
-	f := file{path: path, fullpath: fullPath, name: fi.Name(), depth: depth,
-		size: fi.Size(), isDir: fi.IsDir(), blockSize: 4096, fi: fi}
 	// Firstly, disk usage is estimated with a block size of 4kb,
 	// then it will be precisely calculated with a native syscall.
 	f.diskUsage = avgDiskUsage(f.size, f.blockSize)
@@ -284,7 +775,7 @@ func fullStat(sc *s_scan, path string, depth int64) (*file, error) {
 	case mode&os.ModeNamedPipe != 0:
 		//fmt.Printf("  Named pipe: [%s]\n", f.fullpath)
 		sc.nPipes++
-		if sc.maxStreams > 0 {
+		if len(sc.streams) < sc.maxStreams {
 			s := fmt.Sprintf("[P] %s", f.fullpath)
 			sc.streams = append(sc.streams, s)
 		}
@@ -293,7 +784,7 @@ func fullStat(sc *s_scan, path string, depth int64) (*file, error) {
 	case mode&os.ModeCharDevice != 0:
 		//fmt.Printf("  Character Device: [%s]\n", f.fullpath)
 		sc.nCharDevices++
-		if sc.maxDevices > 0 {
+		if len(sc.devices) < sc.maxDevices {
 			s := fmt.Sprintf("[C] %s", f.fullpath)
 			sc.devices = append(sc.devices, s)
 		}
@@ -302,7 +793,7 @@ func fullStat(sc *s_scan, path string, depth int64) (*file, error) {
 	case mode&os.ModeDevice != 0:
 		//fmt.Printf("  Block device: [%s]\n", f.fullpath)
 		sc.nBlockDevices++
-		if sc.maxDevices > 0 {
+		if len(sc.devices) < sc.maxDevices {
 			s := fmt.Sprintf("[B] %s", f.fullpath)
 			sc.devices = append(sc.devices, s)
 		}
@@ -311,14 +802,14 @@ func fullStat(sc *s_scan, path string, depth int64) (*file, error) {
 	case mode&os.ModeSocket != 0:
 		//fmt.Printf("  Socket: [%s]\n", f.fullpath)
 		sc.nSockets++
-		if sc.maxStreams > 0 {
+		if len(sc.streams) < sc.maxStreams {
 			s := fmt.Sprintf("[S] %s", f.fullpath)
 			sc.streams = append(sc.streams, s)
 		}
 		f.isSpecial = true
 
 	default:
-		m := fmt.Sprintf("  Unknown file type (%v): [%s]\n", mode, f.fullpath)
+		m := fmt.Sprintf(tr("  Unknown file type (%v): [%s]\n"), mode, f.fullpath)
 		push(sc, m)
 	}
 	err = sysStat(sc, &f)
@@ -330,39 +821,62 @@ func fullStat(sc *s_scan, path string, depth int64) (*file, error) {
 }
 
 func printFileTypes(sc *s_scan) { // Summary of file types with non-zero counter
-	fmt.Printf("  Item: %d, Dir: %d, File: %d", sc.nItems, sc.nDirs, sc.nFiles)
+	fmt.Fprintf(sc.out, tr("  Item: %d, Dir: %d, File: %d"), sc.nItems, sc.nDirs, sc.nFiles)
 	if sc.nEmptyDir > 0 {
-		fmt.Printf(", Empty Dir: %d", sc.nEmptyDir)
+		fmt.Fprintf(sc.out, tr(", Empty Dir: %d"), sc.nEmptyDir)
 	}
 	if sc.nSymlinks > 0 {
-		fmt.Printf(", Symlink: %d", sc.nSymlinks)
+		fmt.Fprintf(sc.out, tr(", Symlink: %d"), sc.nSymlinks)
 	}
 	if sc.nHardlinks > 0 {
-		fmt.Printf(",\n  Hardlink: %d", sc.nHardlinks)
+		fmt.Fprintf(sc.out, tr(",\n  Hardlink: %d"), sc.nHardlinks)
 	}
 	if sc.nSockets > 0 {
-		fmt.Printf(", Socket: %d", sc.nSockets)
+		fmt.Fprintf(sc.out, tr(", Socket: %d"), sc.nSockets)
+	}
+	if sc.nSparse > 0 {
+		fmt.Fprintf(sc.out, tr(", Sparse: %d"), sc.nSparse)
 	}
 	if sc.nDenied > 0 {
-		fmt.Printf(", ")
-		msg := fmt.Sprintf("Denied: %d", sc.nDenied)
+		fmt.Fprintf(sc.out, ", ")
+		msg := fmt.Sprintf(tr("Denied: %d"), sc.nDenied)
 		printAlert(sc, msg)
 	}
 	if sc.nErrors > 0 {
-		fmt.Printf(", Error: %d", sc.nErrors)
+		fmt.Fprintf(sc.out, tr(", Error: %d"), sc.nErrors)
+	}
+	if sc.nLocked > 0 {
+		fmt.Fprintf(sc.out, tr(", Locked: %d"), sc.nLocked)
+	}
+	if sc.nRetries > 0 {
+		fmt.Fprintf(sc.out, tr(", Retry: %d"), sc.nRetries)
+	}
+	if sc.nExcluded > 0 {
+		fmt.Fprintf(sc.out, tr(", Excluded: %d"), sc.nExcluded)
+	}
+	if sc.truncated {
+		fmt.Fprintf(sc.out, ", ")
+		printAlert(sc, tr("Truncated: scan budget reached"))
+	}
+	if sc.interrupted {
+		fmt.Fprintf(sc.out, ", ")
+		printAlert(sc, tr("Interrupted: partial results"))
 	}
 	if sc.nBlockDevices > 0 {
-		fmt.Printf(", Block device: %d", sc.nBlockDevices)
+		fmt.Fprintf(sc.out, tr(", Block device: %d"), sc.nBlockDevices)
 	}
 	if sc.nCharDevices > 0 {
-		fmt.Printf(", Character device: %d", sc.nCharDevices)
+		fmt.Fprintf(sc.out, tr(", Character device: %d"), sc.nCharDevices)
+	}
+	fmt.Fprintf(sc.out, tr(", Depth: %d\n"), sc.reachedDepth)
+	if len(sc.dupdirs) > 0 {
+		fmt.Fprintf(sc.out, tr("  Not counted twice: %d bind or overlay mounts\n"), len(sc.dupdirs))
 	}
-	fmt.Printf(", Depth: %d\n", sc.reachedDepth)
 	if sc.showMax {
-		fmt.Printf("  Deepest: %s\n", sc.deepestPath)
-		fmt.Printf("  Longest path (%d): %s\n", sc.maxPathLen, sc.longestPath)
-		fmt.Printf("  Longest name (%d): %s", sc.maxFNameLen, sc.longestFName)
-		fmt.Println()
+		fmt.Fprintf(sc.out, tr("  Deepest: %s\n"), sc.deepestPath)
+		fmt.Fprintf(sc.out, tr("  Longest path (%d): %s\n"), sc.maxPathLen, sc.longestPath)
+		fmt.Fprintf(sc.out, tr("  Longest name (%d): %s"), sc.maxFNameLen, sc.longestFName)
+		fmt.Fprintln(sc.out)
 	}
 }
 
@@ -386,90 +900,352 @@ func countDigits(n int64) int {
 	return c
 }
 
-func scan(sc *s_scan, files *[]file, path string, depth int64) (*file, error) {
-	f, err := fullStat(sc, path, depth)
+func countDevice(sc *s_scan, f *file) {
+	d, ok := sc.devusage[f.deviceId]
+	if !ok {
+		d = &dev_usage{dev: f.deviceId}
+		sc.devusage[f.deviceId] = d
+	}
+	d.items++
+	d.diskUsage += f.diskUsage
+}
+
+/* Returns true once --max-items, --max-bytes or --timeout is reached */
+func overBudget(sc *s_scan) bool {
+	if sc.truncated || sc.interrupted {
+		return true
+	}
+	switch sc.ctx.Err() {
+	case nil:
+	case context.DeadlineExceeded:
+		sc.truncated = true
+		push(sc, tr("  Scan timeout reached, not descending further"))
+		return true
+	default:
+		sc.interrupted = true
+		push(sc, tr("  Interrupted, showing the items scanned so far"))
+		return true
+	}
+	if (sc.maxItems > 0 && sc.nItems >= sc.maxItems) ||
+		(sc.maxBytes > 0 && sc.nBytes >= sc.maxBytes) {
+		sc.truncated = true
+		push(sc, tr("  Scan budget reached, not descending further"))
+		return true
+	}
+	return false
+}
+
+/* Parses sizes such as 512, 20K, 1.5G (powers of 1024) */
+func parseSize(s string) (int64, error) {
+	units, orig := "KMGTP", s
+	m := 1.0
+	if l := len(s); l > 0 {
+		if i := strings.IndexByte(units, strings.ToUpper(s)[l-1]); i >= 0 {
+			m = math.Pow(1024, float64(i+1))
+			s = s[:l-1]
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size: %q", orig)
+	}
+	return int64(v * m), nil
+}
+
+/* Entries of a directory in the order of the filesystem. Through a handle
+ * of the directory, each entry is stat'ed relative to it (see dirEntries);
+ * otherwise the entries are not stat'ed, their type being known from the
+ * directory on most systems, and scan() calls Lstat. */
+func readDir(path string, dir *os.Root) ([]os.DirEntry, error) {
+	if dir == nil {
+		d, err := os.Open(longPath(path))
+		if err != nil {
+			return nil, err
+		}
+		defer d.Close()
+		return d.ReadDir(-1)
+	}
+	d, err := dir.Open(".")
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	return dirEntries(d)
+}
+
+/* Scans path and its content. The handle of its parent directory and its
+ * stat, when known, let the tree be scanned with relative names only. */
+func scan(sc *s_scan, files *[]file, path string, depth int64, parent scan_dir,
+	fi os.FileInfo) (*file, error) {
+	f, err := fullStat(sc, path, depth, fi)
 	if err != nil {
 		// fmt.Println(err)
 		return nil, err
 	}
+	sc.nBytes += f.diskUsage
+	if sc.watcher != nil {
+		sc.watcher.watchItem(path, f)
+	}
+	countXattrs(sc, f)
+	countOwner(sc, f)
+	countAudit(sc, f)
+	countDocker(sc, f)
+	f.fi = nil // system data is no longer needed, and some files are kept
 
 	if !f.isDir {
 		ncduAdd(sc, f)
+		snapAdd(sc, f)
+		jsonlAdd(sc, f, nil)
+		manifestAdd(sc, f)
+		countYear(sc, f)
+		countAge(sc, f)
+		countExt(sc, f)
+		countCategory(sc, f)
+		countSparse(sc, f)
+		countShared(sc, f)
+		countSlack(sc, f)
+		countEmptyFile(sc, f)
+		countLogs(sc, f)
+		countReclaim(sc, f)
 	}
 	if f.isOtherFs {
 		ncduAdd(sc, f)
+		if f.isDir {
+			snapAdd(sc, f)
+		}
+		sc.otherfs = append(sc.otherfs, *f)
 		return f, nil
 	}
+	if sc.tagOtherFs {
+		countDevice(sc, f)
+	}
+	if f.isTagged {
+		f.otherDu = f.diskUsage
+		f.otherDev = f.deviceId
+		if f.deviceId != sc.parentDevice { // mountpoint
+			sc.otherfs = append(sc.otherfs, *f)
+		}
+	}
 	if f.isSymlink || !f.isDir {
 		if files != nil {
 			*files = append(*files, *f)
 		}
+		if !matchAge(sc, f) {
+			return f, nil
+		}
 		if len(sc.bigfiles) > sc.maxBigFiles*4 {
-			sort.Sort(szDesc(sc.bigfiles))
+			sortFiles(sc, sc.bigfiles)
 			sc.bigfiles = sc.bigfiles[0:sc.maxBigFiles]
 		}
 		sc.bigfiles = append(sc.bigfiles, *f)
 		return f, nil
 	}
 
-	fs, err := ioutil.ReadDir(path)
-	if err != nil {
+	select { // the counters are only read by the goroutine of the scan
+	case <-sc.status:
+		showStatus(sc, depth, f.fullpath)
+	default:
+	}
+	if sc.progress || sc.progressJSON != nil {
+		select { // never wait for the progress line
+		case sc.curDir <- f.fullpath:
+		default:
+		}
+	}
+	var dir scan_dir // handle of this directory, nil if it cannot be kept open
+	if dir_HANDLES && depth <= sc.maxHandles {
+		var v interface{}
+		v, err = withDeadline(sc, path, func() (interface{}, error) {
+			return sc.fs.openDir(path, parent)
+		})
+		dir, _ = v.(scan_dir)
+		if dir != nil {
+			defer dir.Close()
+		}
+	}
+	var fs []os.DirEntry
+	if !errors.Is(err, errHung) { // else opening it again would hang as well
+		err = withRetry(sc, func() error {
+			v, err := withDeadline(sc, path, func() (interface{}, error) {
+				return sc.fs.readDir(path, dir)
+			})
+			fs, _ = v.([]os.DirEntry)
+			return err
+		})
+	}
+	if errors.Is(err, errHung) {
+		f.readError = true
+		sc.nErrors++
+		if len(sc.errors) < sc.maxErrors {
+			sc.errors = append(sc.errors, err)
+		}
+	} else if err != nil {
 		sc.nDenied++
 		f.readError = true
-		if sc.maxDenied > 0 {
+		if len(sc.denieddirs) < sc.maxDenied {
 			sc.denieddirs = append(sc.denieddirs, f.path)
 		}
 		// fmt.Printf("ReadDir err on \"%s\", len(fs)=%d\n", path, len(fs))
+	} else if sc.watcher != nil {
+		sc.watcher.watchDir(path)
 	}
 
+	if isCacheDir(sc, path, dir, fs) {
+		sc.cacheDepth = depth
+	}
+	gitSaved := gitEnterDir(sc, path, fs)
 	ncduOpenDir(sc)
 	ncduAdd(sc, f)
+	snapAdd(sc, f)
 
 	var size, du, items int64 = f.size, f.diskUsage, 0
-	var ptr *[]file
+	var nFiles, nTiny int64
+	var otherDu, otherDev = f.otherDu, f.otherDev
+	var ptr, kids *[]file
+	if depth > 1 && depth <= sc.maxDepth {
+		kids = new([]file) // details kept for the breakdown
+	}
 	l := len(fs)
 	if l > 0 {
 		ncduNext(sc)
 	}
 	if l == 0 {
 		sc.nEmptyDir++
-		if sc.maxEmptyDirs > 0 {
+		if len(sc.emptydirs) < sc.maxEmptyDirs {
 			sc.emptydirs = append(sc.emptydirs, f.path)
 		}
 	}
-	for n, i := range fs { // Calculate total size by recursive scanning
+	est := sampleDirs(sc, path, depth, fs)
+	for _, i := range fs { // Calculate total size by recursive scanning
 		ptr = files
 		if depth > 1 {
-			ptr = nil // Forget details for deep directories
+			ptr = kids // nil: forget details of deep directories
 		}
-		items++
 		var subpath string
 		if path == "." {
 			subpath = i.Name()
 		} else {
 			subpath = path + sc.pathSeparator + i.Name()
 		}
-		cf, err := scan(sc, ptr, subpath, depth+1)
+		if overBudget(sc) {
+			break
+		}
+		if est.skipped(i) {
+			continue
+		}
+		if excluded(sc, subpath, i) {
+			continue
+		}
+		ignored := gitIgnored(sc, subpath, i)
+		if ignored {
+			sc.git.ignoring++
+		}
+		pruned := isPruned(sc, i)
+		if pruned {
+			sc.prune.inside++
+		}
+		items++
+		sc.parentDevice = f.deviceId
+		var info os.FileInfo
+		if dir != nil {
+			info, _ = i.Info() // already known, see readDir
+		}
+		cf, err := scan(sc, ptr, subpath, depth+1, dir, info)
+		if err != nil && isLockedErr(err) {
+			if fi, e := i.Info(); e == nil { // from the directory, without opening the file
+				cf, err = lockedFile(sc, ptr, subpath, fi, depth+1), nil
+			}
+		}
+		if ignored {
+			sc.git.ignoring--
+		}
+		if pruned {
+			sc.prune.inside--
+		}
 		if err != nil {
 			//fmt.Println(err)
 			continue
 		}
-		if n < l-1 {
-			ncduNext(sc)
+		if ignored {
+			gitAdd(sc, cf)
 		}
+		if pruned {
+			pruneAdd(sc, cf)
+		}
+		gitRepoChild(sc, path, i.Name(), cf)
+		est.add(i, cf)
+		if cf.isRegular {
+			nFiles++
+			if cf.size < tiny_SIZE {
+				nTiny++
+			}
+		}
+		ncduNext(sc)
 		size += cf.size
 		du += cf.diskUsage
 		items += cf.items
+		otherDu += cf.otherDu
+		if otherDev == 0 {
+			otherDev = cf.otherDev
+		}
 	}
 	fo := file{path: path, name: f.name, size: size, diskUsage: du,
-		isDir: true, depth: depth, items: items}
+		isDir: true, depth: depth, items: items, otherDu: otherDu,
+		otherDev: otherDev}
+	est.extrapolate(sc, &fo)
+	if kids != nil {
+		sortFiles(sc, *kids)
+		if len(*kids) > dft_DEPTHITEMS {
+			*kids = (*kids)[:dft_DEPTHITEMS]
+		}
+		fo.kids = *kids
+	}
 	if depth > 1 && files != nil {
 		*files = append(*files, fo)
 	}
+	gitLeaveDir(sc, gitSaved)
+	gitRepoDone(sc, path, &fo)
+	if sc.cacheDepth == depth {
+		sc.cachedirs = append(sc.cachedirs, fo)
+		sc.cacheDepth = 0
+	}
+	countEntries(sc, path, int64(l), items)
+	countTinyDir(sc, path, nFiles, nTiny)
 	ncduCloseDir(sc)
+	snapCloseDir(sc)
+	jsonlAdd(sc, f, &fo)
 	return &fo, nil
 }
 
+/* Adds a change of the usage of rel to the total, and to the items above
+ * it or equal to it in list, the depth 1 items and their kids (--depth).
+ * items is 1 for a new item and -1 for a removed one, see --watch and
+ * --enable-delete. */
+func apply(list *[]file, t *file, rel string, du, size, items int64) {
+	if t != nil {
+		t.diskUsage += du
+		t.size += size
+		t.items += items
+	}
+	for i := range *list {
+		f := &(*list)[i]
+		if f.path != rel && !strings.HasPrefix(rel, f.path+"/") {
+			continue
+		}
+		if f.path == rel && items < 0 { // the item itself was removed
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			return
+		}
+		f.diskUsage += du
+		f.size += size
+		if f.path != rel {
+			f.items += items
+			apply(&f.kids, nil, rel, du, size, items)
+		}
+		return
+	}
+}
+
 func showmax(sc *s_scan, total *file) {
 	if total.diskUsage == 0 {
 		return
@@ -477,39 +1253,110 @@ func showmax(sc *s_scan, total *file) {
 	if sc.maxBigFiles <= 0 {
 		return
 	}
-	sort.Sort(szDesc(sc.bigfiles)) // sort biggest files by descending size
-	fmt.Println()
-	fmt.Println("  --------- BIGGEST FILES -------------")
+	sortFiles(sc, sc.bigfiles) // sort biggest files by descending size
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("BIGGEST FILES"))
 	var i int = 0
 	var sum, rsum int64 = 0, 0
 	fi := sc.bigfiles
 	for _, f := range fi {
 		i++
+		du := shownSize(sc, f.diskUsage, f.size)
 		if i > sc.maxBigFiles {
-			rsum += f.diskUsage
+			rsum += du
 			continue
 		}
+		sum += du
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\n", du, fullPath(sc, f.path))
+			continue
+		}
+		vm := vmImageInfo(sc, &f)
 		f.path = smartTruncate(f.path, sc.maxNameLen+18)
-		fmt.Printf("%3d.%12s| %s\n", i, fmtSz(sc, f.diskUsage), f.path)
-		sum += f.diskUsage
+		if sc.dual {
+			fmt.Fprintf(sc.out, "%3d.%12s|%12s| %s%s\n", i,
+				fmtSz(sc, f.diskUsage), fmtSz(sc, f.size), f.path, vm)
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s| %s%s\n", i, fmtSz(sc, du),
+			f.path, vm)
+	}
+	if sc.plain {
+		return
+	}
+	x := tr("  =%13s| %.02f%% of total disk usage\n")
+	if sc.apparent {
+		x = tr("  =%13s| %.02f%% of total apparent size\n")
+	}
+	p := float64(sum*100.0) / float64(shownSize(sc, total.diskUsage, total.size))
+	fmt.Fprintf(sc.out, x, fmtSz(sc, sum), p)
+}
+
+func fullPath(sc *s_scan, path string) string {
+	return filepath.Join(sc.root, path)
+}
+
+func listPath(sc *s_scan, path string) string {
+	if sc.plain {
+		return fullPath(sc, path)
+	}
+	return path
+}
+
+func printListItem(sc *s_scan, i int, item interface{}) {
+	if sc.plain {
+		fmt.Fprintf(sc.out, "%v\n", item)
+		return
+	}
+	fmt.Fprintf(sc.out, "%3d. %v\n", i, item)
+}
+
+/* Directories with many entries may exhaust the inodes long before
+ * the disk space. */
+func countEntries(sc *s_scan, path string, entries, items int64) {
+	if sc.maxItemDirs <= 0 || entries == 0 {
+		return
+	}
+	if len(sc.itemdirs) > sc.maxItemDirs*4 {
+		sort.Sort(entriesDesc(sc.itemdirs))
+		sc.itemdirs = sc.itemdirs[0:sc.maxItemDirs]
+	}
+	sc.itemdirs = append(sc.itemdirs, dir_count{path, entries, items})
+}
+
+func showitemdirs(sc *s_scan) {
+	if len(sc.itemdirs) == 0 {
+		return
+	}
+	sort.Sort(entriesDesc(sc.itemdirs))
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("MOST ENTRIES"))
+	for i, d := range sc.itemdirs {
+		i++
+		if i > sc.maxItemDirs {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%s\n", d.entries, d.items, fullPath(sc, d.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%10d|%10d %s| %s\n", i, d.entries, d.items,
+			tr("items"), d.path)
 	}
-	x := "  =%13s| %.02f%% of total disk usage\n"
-	p := float64(sum*100.0) / float64(total.diskUsage)
-	fmt.Printf(x, fmtSz(sc, sum), p)
 }
 
 func showempty(sc *s_scan) {
 	if sc.maxEmptyDirs <= 0 || len(sc.emptydirs) == 0 {
 		return
 	}
-	fmt.Println()
-	fmt.Println("  --------- EMPTY DIRECTORIES ---------")
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("EMPTY DIRECTORIES"))
 	for i, d := range sc.emptydirs {
 		i++
 		if i > sc.maxEmptyDirs {
 			break
 		}
-		fmt.Printf("%3d. %s\n", i, d)
+		printListItem(sc, i, listPath(sc, d))
 	}
 }
 
@@ -517,29 +1364,55 @@ func showdenied(sc *s_scan) {
 	if sc.maxDenied <= 0 || len(sc.denieddirs) == 0 {
 		return
 	}
-	fmt.Println()
-	fmt.Println("  --------- ACCESS DENIED -------------")
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("ACCESS DENIED"))
 	for i, d := range sc.denieddirs {
 		i++
 		if i > sc.maxDenied {
 			break
 		}
-		fmt.Printf("%3d. %s\n", i, d)
+		printListItem(sc, i, listPath(sc, d))
 	}
 }
 
 func showerrors(sc *s_scan) {
+	if len(sc.hung) > 0 { // always shown, as their content is missing
+		fmt.Fprintln(sc.out)
+		fmt.Fprintln(sc.out, header("UNRESPONSIVE PATHS"))
+		for i, p := range sc.hung {
+			printListItem(sc, i+1, p)
+		}
+		if !sc.plain {
+			fmt.Fprintf(sc.out, tr("  Skipped after %v without response (--stat-timeout)\n"),
+				sc.statTimeout)
+		}
+	}
 	if sc.maxErrors <= 0 || len(sc.errors) == 0 {
 		return
 	}
-	fmt.Println()
-	fmt.Println("  --------- FILE STATUS ERROR ---------")
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("FILE STATUS ERROR"))
 	for i, d := range sc.errors {
 		i++
 		if i > sc.maxErrors {
 			break
 		}
-		fmt.Printf("%3d. %s\n", i, d)
+		printListItem(sc, i, d)
+	}
+}
+
+func showlocked(sc *s_scan) {
+	if sc.maxLocked <= 0 || len(sc.locked) == 0 {
+		return
+	}
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("LOCKED FILES"))
+	for i, d := range sc.locked {
+		i++
+		if i > sc.maxLocked {
+			break
+		}
+		printListItem(sc, i, listPath(sc, d))
 	}
 }
 
@@ -547,14 +1420,14 @@ func showstreams(sc *s_scan) {
 	if sc.maxStreams <= 0 || len(sc.streams) == 0 {
 		return
 	}
-	fmt.Println()
-	fmt.Println("  --------- SOCKETS AND PIPES ---------")
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("SOCKETS AND PIPES"))
 	for i, d := range sc.streams {
 		i++
 		if i > sc.maxStreams {
 			break
 		}
-		fmt.Printf("%3d. %s\n", i, d)
+		printListItem(sc, i, d)
 	}
 }
 
@@ -562,35 +1435,175 @@ func showdevices(sc *s_scan) {
 	if sc.maxDevices <= 0 || len(sc.devices) == 0 {
 		return
 	}
-	fmt.Println()
-	fmt.Println("  --------- DEVICES -------------------")
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("DEVICES"))
 	for i, d := range sc.devices {
 		i++
 		if i > sc.maxDevices {
 			break
 		}
-		fmt.Printf("%3d. %s\n", i, d)
+		printListItem(sc, i, d)
 	}
 }
 
+func showotherfs(sc *s_scan) {
+	if len(sc.otherfs) == 0 {
+		return
+	}
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("OTHER FILESYSTEMS"))
+	fmt.Fprintf(sc.out, "    %12s|%12s|%12s| %s\n", tr("Size"), tr("Used"), tr("Free"), tr("Mountpoint"))
+	for i, f := range sc.otherfs {
+		i++
+		total, used, avail, ok := fsStat(f.path)
+		if !ok {
+			fmt.Fprintf(sc.out, "%3d.%12s|%12s|%12s| %s\n", i, "?", "?", "?", f.fullpath)
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s|%12s|%12s| %s\n", i, fmtSz(sc, int64(total)),
+			fmtSz(sc, int64(used)), fmtSz(sc, int64(avail)), f.fullpath)
+	}
+}
+
+func showdevusage(sc *s_scan, total *file) {
+	if len(sc.devusage) < 2 || total.diskUsage == 0 {
+		return
+	}
+	var du []*dev_usage
+	for _, d := range sc.devusage {
+		du = append(du, d)
+	}
+	sort.Slice(du, func(i, j int) bool {
+		return du[i].diskUsage > du[j].diskUsage
+	})
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("USAGE PER DEVICE"))
+	for i, d := range du {
+		i++
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%s\n", d.diskUsage, d.items, getPartition(sc, d.dev))
+			continue
+		}
+		p := float64(d.diskUsage*100.0) / float64(total.diskUsage)
+		fmt.Fprintf(sc.out, "%3d.%12s|%6.2f%%|%8d "+tr("items")+"| %s\n", i, fmtSz(sc, d.diskUsage),
+			p, d.items, getPartition(sc, d.dev))
+	}
+}
+
+func shareLevel(sc *s_scan, p float64) int {
+	if !sc.colors || !sc.tty {
+		return share_LOW
+	}
+	if p > sc.colorHigh {
+		return share_HIGH
+	}
+	if p > sc.colorMid {
+		return share_MID
+	}
+	return share_LOW
+}
+
+/* Bar of n characters, filled in proportion of v to max (--bar) */
+func tableBar(n int, v, max int64) string {
+	full := 0
+	if max > 0 {
+		full = int((v*int64(n) + max/2) / max)
+	}
+	if full > n {
+		full = n
+	}
+	return strings.Repeat(bar_FULL, full) + strings.Repeat(bar_EMPTY, n-full)
+}
+
+/* Disk usage column, followed by apparent size in dual mode */
+func sizeColumns(sc *s_scan, cf string, du, size int64) string {
+	if !sc.dual {
+		du = shownSize(sc, du, size)
+	}
+	s := fmt.Sprintf(cf, fmtSz(sc, du))
+	if sc.dual {
+		s += "|" + fmt.Sprintf(cf, fmtSz(sc, size))
+	}
+	return s
+}
+
+/* Indented breakdown of the largest entries of a directory (--depth),
+ * aligned on the columns of the table after an empty name. */
+func showKids(sc *s_scan, kids []file, level int, margin, cf string, total *file) {
+	t := shownSize(sc, total.diskUsage, total.size)
+	for _, k := range kids {
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\n", shownSize(sc, k.diskUsage, k.size),
+				fullPath(sc, k.path))
+			showKids(sc, k.kids, level+1, margin, cf, total)
+			continue
+		}
+		var p float64
+		if t > 0 {
+			p = float64(shownSize(sc, k.diskUsage, k.size)*100.0) / float64(t)
+		}
+		name := k.name
+		if k.isDir {
+			name += "/"
+		}
+		row := fmt.Sprintf("%s|%s|%6.2f%%| %s- %s", margin,
+			sizeColumns(sc, cf, k.diskUsage, k.size), p,
+			strings.Repeat("  ", level-1), name)
+		printShare(sc, shareLevel(sc, p), row)
+		fmt.Fprintln(sc.out)
+		showKids(sc, k.kids, level+1, margin, cf, total)
+	}
+}
+
+/* Greppable output: raw bytes and full paths, without padding */
+func showPlain(sc *s_scan, fi []file, total *file) {
+	i := 0
+	for _, f := range fi {
+		if !f.isDir && sc.nFiles == 0 { // ignore special files
+			continue
+		}
+		i++
+		if i > sc.maxShownLines {
+			break
+		}
+		fmt.Fprintf(sc.out, "%d\t%s\n", shownSize(sc, f.diskUsage, f.size),
+			fullPath(sc, f.path))
+		showKids(sc, f.kids, 1, "", "", total)
+	}
+	fmt.Fprintf(sc.out, "%d\ttotal\n", shownSize(sc, total.diskUsage, total.size))
+	fmt.Fprintln(sc.out)
+	printFileTypes(sc)
+}
+
 func show(sc *s_scan, fi []file, total *file) {
 	if sc.foundBoundary {
-		fmt.Println()
+		fmt.Fprintln(sc.out)
 	}
 	if total.diskUsage == 0 {
-		fmt.Println("  Total disk usage is zero.")
+		fmt.Fprintln(sc.out, tr("  Total disk usage is zero."))
 		printFileTypes(sc)
 		return
 	}
-	sort.Sort(szDesc(fi))    // sort files and folders by descending size
+	sortTable(sc, fi) // sort files and folders, by descending size by default
+	if sc.plain {
+		showPlain(sc, fi, total)
+		return
+	}
 	var fmtNameLen int = 11  // minimum for the total line
 	var rDiskUsage int64 = 0 // remaining disk usage
+	var rSize int64 = 0      // remaining apparent size
 	var rItems int64 = 0     // remaining items
 	var i int = 0
+	for _, t := range []string{"REMAINING", "DISK SPACE", "TOTAL SIZE"} {
+		if l := utf8.RuneCountInString(tr(t)) + 1; l > fmtNameLen {
+			fmtNameLen = l // translated labels
+		}
+	}
 	for _, f := range fi { // Totals and max len loop
 		i++
 		if i > sc.maxShownLines {
 			rDiskUsage += f.diskUsage
+			rSize += f.size
 			rItems += f.items
 			if f.isDir {
 				rItems++
@@ -610,9 +1623,42 @@ func show(sc *s_scan, fi []file, total *file) {
 		fmtNameLen = sc.maxNameLen
 	}
 	nf := fmt.Sprintf("%%%ds", fmtNameLen+1)
-	cf := fmt.Sprintf("%%%ds", countDigits(total.diskUsage)+1)
+	width := total.diskUsage
+	if total.size > width {
+		width = total.size
+	}
+	cf := fmt.Sprintf("%%%ds", countDigits(width)+1)
 	mf := fmt.Sprintf("%%%dd", countDigits(sc.nItems)+1)
-	var strfmt = "%3d." + nf + "|" + cf + "|%6.2f%%|"
+	var strfmt = "%3d." + nf + "|%s|%6.2f%%|"
+	var barLen int   // width of the bar column, derived from the console width
+	var barMax int64 // largest entry, which gets a full bar
+	if sc.bars {
+		cols := countDigits(width) + 1
+		if sc.dual {
+			cols = cols*2 + 1
+		}
+		fixed := 4 + fmtNameLen + 1 + 1 + cols + 1 + 7 + 1 +
+			countDigits(sc.nItems) + 2 + utf8.RuneCountInString(tr("items"))
+		barLen = sc.maxWidth - fixed - 2
+		if barLen > cst_TABLEBAR {
+			barLen = cst_TABLEBAR
+		}
+		if barLen < 5 {
+			barLen = 0
+		}
+		for j, f := range fi {
+			if j >= sc.maxShownLines {
+				break
+			}
+			if s := shownSize(sc, f.diskUsage, f.size); s > barMax {
+				barMax = s
+			}
+		}
+	}
+	if sc.dual {
+		h := "    " + nf + "|" + cf + "|" + cf + "|\n"
+		fmt.Fprintf(sc.out, h, "", tr("Disk"), tr("Apparent"))
+	}
 	i = 0
 	for _, f := range fi {
 		if !f.isDir && sc.nFiles == 0 { // ignore special files
@@ -622,30 +1668,47 @@ func show(sc *s_scan, fi []file, total *file) {
 		if i > sc.maxShownLines { // stop
 			break
 		}
+		delta := baselineDelta(sc, &f)
 		if f.isDir {
 			f.name += "/"
 		}
 		f.name = smartTruncate(f.name, sc.maxNameLen)
 		var p float64 = 0
-		if total.diskUsage > 0 {
-			p = float64(f.diskUsage*100.0) / float64(total.diskUsage)
+		if t := shownSize(sc, total.diskUsage, total.size); t > 0 {
+			p = float64(shownSize(sc, f.diskUsage, f.size)*100.0) / float64(t)
+		}
+		sz := sizeColumns(sc, cf, f.diskUsage, f.size)
+		row := fmt.Sprintf(strfmt, i, f.name, sz, p)
+		if barLen > 0 {
+			row += tableBar(barLen, shownSize(sc, f.diskUsage, f.size), barMax) + "|"
 		}
-		fmt.Printf(strfmt, i, f.name, fmtSz(sc, f.diskUsage), p)
 		if f.isDir {
-			fmt.Printf(mf+" items", f.items)
+			row += fmt.Sprintf(mf+" "+tr("items"), f.items)
 		}
-		fmt.Println()
+		if f.otherDu > 0 {
+			row += fmt.Sprintf(" [dev 0x%04X: %s]", f.otherDev, fmtSz(sc, f.otherDu))
+		}
+		row += delta
+		printShare(sc, shareLevel(sc, p), row)
+		fmt.Fprintln(sc.out)
+		showKids(sc, f.kids, 1, "    "+fmt.Sprintf(nf, ""), cf, total)
 	}
 	strfmt = "    " + nf + "|" + cf + "|" // spaces for line number width
 	if rDiskUsage > 0 {
-		p := float64(rDiskUsage*100.0) / float64(total.diskUsage)
-		s := strfmt + "%6.2f%%|" + mf + " items\n"
-		fmt.Printf(s, "REMAINING", fmtSz(sc, rDiskUsage), p, rItems)
+		p := float64(shownSize(sc, rDiskUsage, rSize)*100.0) /
+			float64(shownSize(sc, total.diskUsage, total.size))
+		s := "    " + nf + "|%s|%6.2f%%|" + mf + " " + tr("items") + "\n"
+		if barLen > 0 {
+			s = "    " + nf + "|%s|%6.2f%%|" + strings.Repeat(" ", barLen) + "|" +
+				mf + " " + tr("items") + "\n"
+		}
+		sz := sizeColumns(sc, cf, rDiskUsage, rSize)
+		fmt.Fprintf(sc.out, s, tr("REMAINING"), sz, p, rItems)
 	}
 	strfmt += "\n"
-	fmt.Printf(strfmt, "DISK SPACE", fmtSz(sc, total.diskUsage))
-	fmt.Printf(strfmt, "TOTAL SIZE", fmtSz(sc, total.size))
-	fmt.Println()
+	fmt.Fprintf(sc.out, strfmt, tr("DISK SPACE"), fmtSz(sc, total.diskUsage))
+	fmt.Fprintf(sc.out, strfmt, tr("TOTAL SIZE"), fmtSz(sc, total.size))
+	fmt.Fprintln(sc.out)
 	printFileTypes(sc)
 }
 
@@ -671,31 +1734,120 @@ func changeDir(args []string) (string, error) {
 /* Check command line arguments */
 func usage(sc *s_scan) []string {
 	flag.Usage = func() {
-		showTitle()
+		showTitle(sc)
 		fmt.Println(" Copyright (c) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>")
 		fmt.Println(" https://github.com/josephpaul0/tdu")
 		fmt.Println()
-		fmt.Printf(" Usage: %s [options] [directory]\n", os.Args[0])
+		fmt.Printf(tr(" Usage: %s [options] [directory...]\n"), os.Args[0])
+		fmt.Printf(tr("        %s compare [options] DIR1 DIR2\n"), os.Args[0])
+		fmt.Printf(tr("        %s history [options] [DIR]\n"), os.Args[0])
 		fmt.Println()
 		flag.PrintDefaults()
 		fmt.Println()
-		fmt.Printf(" Compiled with Go version %s", runtime.Version())
+		fmt.Printf(tr(" Compiled with Go version %s"), runtime.Version())
 		fmt.Println()
 		fmt.Println()
 	}
-	mb := flag.Int("b", dft_MAXBIGFILES, "Number of big files shown")
-	ml := flag.Int("l", dft_MAXSHOWNLINES, "Number of depth1 items shown")
-	me := flag.Int("e", dft_MAXEMPTYDIRS, "Number of empty directories shown (default 0)")
-	md := flag.Int("d", dft_MAXDENIEDDIRS, "Number of access denied directories shown (default 0)")
-	ms := flag.Int("s", dft_MAXSTATERROR, "Number of file status errors shown (default 0)")
-	mf := flag.Int("f", dft_MAXDEVICES, "Number of devices shown (default 0)")
-	mt := flag.Int("t", dft_MAXSTREAMS, "Number of sockets and named pipes shown (default 0)")
-	ex := flag.String("o", "", "Export result to Ncdu's JSON format")
-	nm := flag.Bool("max", false, "Show deepest and longest paths")
-	vs := flag.Bool("version", false, "Program info and usage")
-	sl := flag.Bool("license", false, "Show the GNU General Public License V2")
-	hu := flag.Bool("human", true, "Print sizes in human readable format.\nUse --human=false to print in kilobytes instead.")
-	cm := flag.Bool("consolemax", false, "Maximize console window (on Windows only)")
+	mb := flag.Int("b", dft_MAXBIGFILES, tr("Number of big files shown"))
+	mc := flag.Int("i", dft_MAXITEMDIRS, tr("Number of directories with the most entries shown (default 0)"))
+	ml := flag.Int("l", dft_MAXSHOWNLINES, tr("Number of depth1 items shown"))
+	me := flag.Int("e", dft_MAXEMPTYDIRS, tr("Number of empty directories shown (default 0)"))
+	ef := flag.Int("empty-files", 0, tr("Number of zero-byte files and directories of tiny files shown (default 0)"))
+	md := flag.Int("d", dft_MAXDENIEDDIRS, tr("Number of access denied directories shown (default 0)"))
+	ms := flag.Int("s", dft_MAXSTATERROR, tr("Number of file status errors shown (default 0)"))
+	mf := flag.Int("f", dft_MAXDEVICES, tr("Number of devices shown (default 0)"))
+	mk := flag.Int("k", dft_MAXLOCKED, tr("Number of files locked by other processes shown (Windows)"))
+	ma := flag.Int("x", dft_MAXXATTRS, tr("Number of files with the biggest extended attributes shown (Linux, default 0)"))
+	mp := flag.Int("p", dft_MAXSPARSE, tr("Number of sparse files shown (default 0)"))
+	mt := flag.Int("t", dft_MAXSTREAMS, tr("Number of sockets and named pipes shown (default 0)"))
+	ex := flag.String("o", "", tr("Export result to Ncdu's JSON format (- for stdout)"))
+	nm := flag.Bool("max", false, tr("Show deepest and longest paths"))
+	vs := flag.Bool("version", false, tr("Program info and usage"))
+	sl := flag.Bool("license", false, tr("Show the GNU General Public License V2"))
+	hu := flag.Bool("human", true, tr("Print sizes in human readable format.\nUse --human=false to print in kilobytes instead."))
+	bt := flag.Bool("bytes", false, tr("Print sizes as exact byte counts"))
+	bu := flag.String("B", "", tr("Print sizes in units of this size (e.g. 4K, 1M)"))
+	br := flag.Bool("bar", false, tr("Show a bar of each item's usage in the table"))
+	qu := flag.Bool("q", false, tr("Quiet: only print the results, without title, progress nor partition info"))
+	pj := flag.String("progress-json", "", tr("Write progress events as JSON Lines to stderr or to a file descriptor number"))
+	si := flag.Bool("si", false, tr("Use powers of 1000 (kB, MB, GB) instead of 1024 (KiB, MiB, GiB)"))
+	dp := flag.Int64("depth", dft_DEPTH, tr("Number of directory levels shown"))
+	ed := flag.Bool("enable-delete", false, tr("Offer to delete the biggest files, after confirmation"))
+	sv := flag.String("save", "", tr("Save the scan to a snapshot file"))
+	hs := flag.Bool("history", false, tr("Append a summary of the scan to the history shown by 'tdu history'"))
+	rn := flag.Int("runs", dft_HISTORYRUNS, tr("Number of runs shown by 'tdu history'"))
+	fo := flag.String("folded", "", tr("Write the size tree as folded stacks for flame graphs to a file"))
+	gv := flag.String("dot", "", tr("Write the size tree as a Graphviz DOT graph to a file"))
+	ld := flag.String("load", "", tr("Show a saved snapshot instead of scanning"))
+	im := flag.String("import", "", tr("Show an Ncdu JSON export instead of scanning"))
+	ar := flag.String("archive", "", tr("Show the content of a zip or tar archive instead of scanning"))
+	wa := flag.Bool("watch", false, tr("Keep watching the directory after the scan (Linux only)"))
+	so := flag.String("sort", "size", tr("Sort the table by size, items, name or apparent"))
+	rv := flag.Bool("reverse", false, tr("Reverse the order of the table"))
+	ap := flag.Bool("apparent", false, tr("Show and sort by apparent size instead of disk usage"))
+	du := flag.Bool("dual", false, tr("Show both disk usage and apparent size columns"))
+	pr := flag.Int("precision", dft_PRECISION, tr("Number of decimals in human readable sizes"))
+	co := flag.Bool("color", true, tr("Color table rows by share of total disk usage.\nUse --color=false to disable colors."))
+	ch := flag.Float64("color-high", dft_COLORHIGH, tr("Share of total disk usage (%) shown in red"))
+	cd := flag.Float64("color-mid", dft_COLORMID, tr("Share of total disk usage (%) shown in yellow"))
+	cm := flag.Bool("consolemax", false, tr("Maximize console window (on Windows only)"))
+	tg := flag.Bool("tag-other-fs", false, tr("Descend into other filesystems and tag their usage"))
+	cf := flag.Bool("cross-fs", false, tr("Same as --tag-other-fs"))
+	ip := flag.Bool("include-pseudo", false, tr("Also cross into pseudo filesystems (proc, sysfs, tmpfs...)"))
+	mn := flag.String("manifest", "", tr("Write SHA-256, size, mtime and path of every file"))
+	mm := flag.Int64("manifest-max", 0, tr("Do not hash files bigger than n MB (0: no limit)"))
+	mi := flag.Int64("max-items", 0, tr("Stop scanning after n items (0: no limit)"))
+	mx := flag.String("max-bytes", "", tr("Stop scanning after this disk usage (e.g. 500M, 2G)"))
+	to := flag.Duration("timeout", 0, tr("Stop scanning after this duration (e.g. 90s, 10m)"))
+	de := flag.Bool("deterministic", false, tr("Same output for the same tree: ties sorted by path, no times, width of 80 columns"))
+	es := flag.Float64("estimate", 0, tr("Only scan this fraction of the subdirectories (e.g. 0.1) and extrapolate"))
+	st := flag.Duration("stat-timeout", dft_STATTIMEOUT, tr("Skip a path that does not answer in this time, like a dead NFS mount (0: wait)"))
+	rt := flag.Int("retries", dft_RETRIES, tr("Retries after a transient network filesystem error"))
+	rd := flag.Int("retry-delay", dft_RETRYDELAY, tr("Milliseconds before the first retry, doubled each time"))
+	xe := flag.Bool("xattr-exclude", false, tr("Skip directories tagged with user.tdu.exclude=1\nor user.xdg.robots.{index,backup}=false (Linux)"))
+	ec := flag.Bool("exclude-caches", false, tr("Show the usage of directories tagged with CACHEDIR.TAG apart"))
+	ea := flag.Bool("exclude-caches-all", false, tr("Skip directories tagged with CACHEDIR.TAG"))
+	rg := flag.Bool("respect-gitignore", false, tr("Show the usage of items ignored by git apart, in git repositories"))
+	eg := flag.Bool("exclude-gitignored", false, tr("Skip items ignored by git, in git repositories"))
+	pp := flag.String("prune-preset", "", tr("Show apart the usage of the build directories of a preset: dev"))
+	pn := flag.Bool("prune", false, tr("Skip the directories of the --prune-preset"))
+	flag.Var(&sc.excludes, "exclude", tr("Skip items matching a shell pattern (repeatable)"))
+	flag.Var(patternFile{&sc.excludes}, "exclude-from", tr("Read --exclude patterns from a file (one per line)"))
+	by := flag.Bool("by-year", false, tr("Show disk usage per modification year"))
+	ot := flag.String("older-than", "", tr("Only list files older than this (e.g. 180d, 2y)"))
+	nt := flag.String("newer-than", "", tr("Only list files newer than this (e.g. 12h, 7d)"))
+	ag := flag.Bool("age", false, tr("Show disk usage by age and the largest files unmodified for a year"))
+	sx := flag.Bool("shared", false, tr("Show exclusive and shared extents of each item (Linux only)"))
+	cz := flag.Bool("compression", false, tr("Show the compression ratio of each item (disk usage against apparent size)"))
+	ct := flag.Bool("categories", false, tr("Show disk usage per category of files: videos, images, archives..."))
+	bo := flag.Bool("by-owner", false, tr("Show disk usage per user and group (not on Windows)"))
+	be := flag.Int("by-ext", 0, tr("Number of file extensions shown with their usage (default 0)"))
+	dk := flag.Bool("docker", false, tr("Show the usage of each Docker or Podman image, container and volume"))
+	sk := flag.Bool("slack", false, tr("Show the space lost to the rounding of files to whole blocks"))
+	in := flag.Bool("inodes", false, tr("Show the inodes used by each item, against the inode table of the filesystem"))
+	au := flag.Bool("audit", false, tr("List world-writable, setuid and setgid files, and files of deleted users (not on Windows)"))
+	gr := flag.Bool("git-repos", false, tr("Show the usage of each git repository, its history against its working tree"))
+	lo := flag.Bool("logs", false, tr("Show the usage of the systemd journal, the largest rotated logs and the growing ones"))
+	rc := flag.Bool("reclaimable", false, tr("Show the space that cleaning package caches, user caches, trash and old kernels would free"))
+	mo := flag.Bool("mounts", false, tr("List the mounted filesystems with their usage, like df"))
+	am := flag.Bool("all-mounts", false, tr("Scan each local mountpoint in turn"))
+	al := flag.Bool("all-local", false, tr("Same as --all-mounts"))
+	dr := flag.Bool("drives", false, tr("List the fixed drives with their size and free space (Windows)"))
+	sy := flag.Bool("system", false, tr("Scan / per mount, skipping /proc /sys /dev /run"))
+	sn := flag.Bool("snapshots", false, tr("Scan snapper and timeshift snapshot directories"))
+	bs := flag.Bool("btrfs-subvol", false, tr("Do not cross btrfs subvolume boundaries (Linux only)"))
+	ul := flag.String("usage-log", "", tr("Append partition usage to a JSON Lines file\nand predict when the filesystem will be full"))
+	of := flag.String("output", "", tr("Also write the report to a file (without colors)"))
+	bl := flag.String("baseline", "", tr("Show growth since a previous Ncdu JSON export"))
+	pl := flag.Bool("plain", false, tr("Print raw bytes and full paths without padding\n(default when the output is not a terminal)"))
+	flag.String("lang", "", tr("Language of the messages: en, fr, de (default from LANG)"))
+	ix := flag.Bool("influx", false, tr("Print the usage of each item in the InfluxDB line protocol"))
+	gp := flag.Bool("graphite", false, tr("Print the usage of each item in the Graphite plaintext protocol"))
+	gm := flag.Bool("markdown", false, tr("Print the main table, the biggest files and a summary as Markdown tables"))
+	fm := flag.String("format", "", tr("Print the tables as")+" "+strings.Join(formatNames(), ", "))
+	tp := flag.String("template", "", tr("Print the results with a Go template file (text/template)"))
+	dc := flag.Bool("du", false, tr("Print \"SIZE<tab>PATH\" lines in kilobytes like du -skx"))
+	jl := flag.Bool("jsonl", false, tr("Stream scanned entries to stdout as JSON Lines\n(the report is then printed on stderr)"))
 	flag.Parse() // NArg (int)
 	if *sl {
 		showLicense()
@@ -718,10 +1870,15 @@ func usage(sc *s_scan) []string {
 	if *mb >= 0 {
 		sc.maxBigFiles = *mb
 	}
+	sc.maxItemDirs = dft_MAXITEMDIRS
+	if *mc >= 0 {
+		sc.maxItemDirs = *mc
+	}
 	sc.maxEmptyDirs = dft_MAXEMPTYDIRS
 	if *me >= 0 {
 		sc.maxEmptyDirs = *me
 	}
+	sc.maxEmptyFiles = *ef
 	sc.maxDenied = dft_MAXDENIEDDIRS
 	if *md >= 0 {
 		sc.maxDenied = *md
@@ -734,26 +1891,322 @@ func usage(sc *s_scan) []string {
 	if *mf >= 0 {
 		sc.maxDevices = *mf
 	}
+	sc.maxLocked = dft_MAXLOCKED
+	if *mk >= 0 {
+		sc.maxLocked = *mk
+	}
+	sc.maxXattrs = dft_MAXXATTRS
+	if *ma >= 0 {
+		sc.maxXattrs = *ma
+	}
+	sc.maxSparse = dft_MAXSPARSE
+	if *mp >= 0 {
+		sc.maxSparse = *mp
+	}
 	sc.maxStreams = dft_MAXSTREAMS
 	if *mt >= 0 {
 		sc.maxStreams = *mt
 	}
 	sc.showMax = *nm
 	sc.humanReadable = *hu
+	sc.si = *si
+	sc.bars = *br
+	sc.quiet = *qu
+	if *bt && *bu != "" {
+		fmt.Print(tr("\n  [ERROR] --bytes and -B cannot be used together\n\n"))
+		os.Exit(2)
+	}
+	if *bt {
+		sc.unit, sc.unitLabel = 1, "B"
+	}
+	if *bu != "" {
+		n, err := parseSize(*bu)
+		if err != nil || n < 1 {
+			fmt.Printf(tr("\n  [ERROR] -B: invalid size: %q\n\n"), *bu)
+			os.Exit(2)
+		}
+		sc.unit, sc.unitLabel = n, *bu
+	}
+	sc.dual = *du
+	sc.apparent = *ap
+	switch *so {
+	case "size", "items", "name", "apparent":
+		sc.sortBy = *so
+	default:
+		fmt.Printf(tr("\n  [ERROR] Unknown sort order %q\n\n"), *so)
+		os.Exit(2)
+	}
+	sc.reverse = *rv
+	sc.maxDepth = *dp
+	sc.enableDelete = *ed
+	sc.watch = *wa
+	sc.savePath = *sv
+	sc.dotPath = *gv
+	sc.foldedPath = *fo
+	sc.history = *hs
+	sc.historyRuns = *rn
+	if *pj != "" {
+		w, err := progressWriter(*pj)
+		if err != nil {
+			fmt.Printf(tr("\n  [ERROR] Cannot write progress events: %v\n\n"), err)
+			os.Exit(2)
+		}
+		sc.progressJSON = w
+	}
+	sc.loadPath = *ld
+	sc.importPath = *im
+	sc.archivePath = *ar
+	sc.precision = dft_PRECISION
+	if *pr >= 0 {
+		sc.precision = *pr
+	}
 	sc.consoleMax = *cm
+	sc.colors = *co
+	sc.colorHigh = *ch
+	sc.colorMid = *cd
+	sc.tagOtherFs = *tg || *cf
+	sc.includePseudo = *ip
+	sc.subvolumes = *bs
+	sc.snapshots = *sn
+	sc.xattrExclude = *xe
+	sc.excludeCaches = *ec
+	sc.skipCaches = *ea
+	if *rg || *eg {
+		sc.git = &git_state{skip: *eg}
+	}
+	if *pp != "" {
+		if sc.prune = newPruneState(*pp, *pn); sc.prune == nil {
+			fmt.Printf(tr("\n  [ERROR] Unknown prune preset %q\n\n"), *pp)
+			os.Exit(2)
+		}
+	}
+	if *by {
+		sc.years = make(map[int]int64)
+	}
+	if *ag {
+		sc.ages = make([]int64, len(ageBuckets))
+	}
+	sc.compression = *cz
+	sc.inodeReport = *in
+	if *au {
+		sc.audit = &audit_report{modes: make(map[string]os.FileMode),
+			orphans: make(map[uint32]*owner_usage)}
+	}
+	sc.dockerReport = *dk
+	sc.logReport = *lo
+	sc.reclaimReport = *rc
+	if *sx {
+		sc.shared = make(map[string]*extent_usage)
+	}
+	if *gr {
+		sc.repos = make(map[string]*git_repo)
+	}
+	if *sk {
+		sc.slack = make(map[string]*slack_usage)
+	}
+	if *bo {
+		sc.owners = make(map[uint32]*owner_usage)
+		sc.groups = make(map[uint32]*owner_usage)
+	}
+	if *be > 0 {
+		sc.maxExts = *be
+		sc.exts = make(map[string]*ext_usage)
+	}
+	if *ct {
+		sc.categories = make(map[string]*ext_usage)
+	}
+	sc.manifestPath = *mn
+	sc.manifestMax = *mm * 1024 * 1024
+	sc.maxItems = *mi
+	sc.timeout = *to
+	sc.statTimeout = *st
+	if *es < 0 || *es > 1 {
+		fmt.Print(tr("\n  [ERROR] --estimate takes a fraction between 0 and 1\n\n"))
+		os.Exit(2)
+	}
+	sc.estimate = *es
+	sc.deterministic = *de
+	sc.retries = *rt
+	sc.retryDelay = *rd
+	if *mx != "" {
+		n, err := parseSize(*mx)
+		if err != nil {
+			fmt.Printf(tr("\n  [ERROR] --max-bytes: %v\n\n"), err)
+			os.Exit(2)
+		}
+		sc.maxBytes = n
+	}
+	for _, a := range []struct {
+		name string
+		val  string
+		dst  *time.Duration
+	}{{"older-than", *ot, &sc.olderThan}, {"newer-than", *nt, &sc.newerThan}} {
+		if a.val == "" {
+			continue
+		}
+		d, err := parseAge(a.val)
+		if err != nil {
+			fmt.Printf(tr("\n  [ERROR] --%s: %v\n\n"), a.name, err)
+			os.Exit(2)
+		}
+		*a.dst = d
+	}
+	if *am || *al {
+		if len(args) > 0 || *ex != "" || *mn != "" {
+			fmt.Print(tr("\n  [ERROR] --all-mounts takes no directory, export or manifest file\n\n"))
+			os.Exit(2)
+		}
+		sc.allMounts = true
+	}
+	if *dr {
+		if runtime.GOOS != "windows" {
+			fmt.Print(tr("\n  [ERROR] --drives only works on Windows, see --mounts\n\n"))
+			os.Exit(2)
+		}
+		*mo = true
+	}
+	if *mo {
+		if len(args) > 0 || *ex != "" || *mn != "" || sc.allMounts {
+			fmt.Print(tr("\n  [ERROR] --mounts takes no directory, export or manifest file\n\n"))
+			os.Exit(2)
+		}
+		sc.mountList = true
+	}
+	if *sy {
+		sc.system = true
+		sc.tagOtherFs = false // one mountpoint at a time
+		if len(args) == 0 {
+			args = []string{"/"}
+		}
+	}
+	sc.usageLog = *ul
+	sc.plain = *pl
+	if *bl != "" {
+		if err := loadBaseline(sc, *bl); err != nil {
+			fmt.Printf(tr("\n  [ERROR] Cannot read baseline: %v\n\n"), err)
+			os.Exit(1)
+		}
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "plain" {
+			sc.plainSet = true
+		}
+	})
+	if *jl {
+		sc.jsonl = true
+		sc.con = os.Stderr
+		sc.out = sc.con
+	}
+	if *of != "" {
+		f, err := os.Create(*of)
+		if err != nil {
+			fmt.Printf(tr("\n  [ERROR] Cannot create output file: %v\n\n"), err)
+			os.Exit(1)
+		}
+		sc.report = f
+		sc.out = io.MultiWriter(sc.con, f)
+	}
+	if *ix {
+		*fm = "influx"
+	}
+	if *gp {
+		*fm = "graphite"
+	}
+	if *gm {
+		*fm = "markdown"
+	}
+	if *tp != "" {
+		if *fm != "" || sc.jsonl {
+			fmt.Print(tr("\n  [ERROR] --template cannot be used with --jsonl or --format\n\n"))
+			os.Exit(2)
+		}
+		t, err := parseTemplate(sc, *tp)
+		if err != nil {
+			fmt.Printf(tr("\n  [ERROR] Cannot read template: %v\n\n"), err)
+			os.Exit(1)
+		}
+		sc.reporter = templateReporter{t}
+	} else if *fm != "" {
+		r, ok := reporters[*fm]
+		if !ok || sc.jsonl {
+			fmt.Printf(tr("\n  [ERROR] Unknown format %q (or used with --jsonl)\n\n"), *fm)
+			os.Exit(2)
+		}
+		sc.reporter = r
+	}
+	if sc.reporter != nil {
+		sc.rep = os.Stdout
+		if sc.report != nil {
+			sc.rep = io.MultiWriter(os.Stdout, sc.report)
+		}
+		sc.con = os.Stderr
+		sc.out = ioutil.Discard
+	}
+	if *dc {
+		if sc.reporter != nil || sc.jsonl {
+			fmt.Print(tr("\n  [ERROR] --du cannot be used with --jsonl or --format\n\n"))
+			os.Exit(2)
+		}
+		sc.duOutput = true
+		sc.rep = os.Stdout
+		if sc.report != nil {
+			sc.rep = io.MultiWriter(os.Stdout, sc.report)
+		}
+		sc.con = os.Stderr
+		sc.out = ioutil.Discard
+	}
 	if *ex != "" {
 		sc.export = true
 		sc.exportPath = *ex
+		if *ex == "-" { // streamed to stdout, e.g. into ncdu -f -
+			if sc.jsonl || sc.reporter != nil || sc.duOutput {
+				fmt.Print(tr("\n  [ERROR] -o - cannot be used with --jsonl, --du or --format\n\n"))
+				os.Exit(2)
+			}
+			sc.con = os.Stderr
+			sc.out = sc.con
+			if sc.report != nil {
+				sc.out = io.MultiWriter(sc.con, sc.report)
+			}
+		}
 	}
-	if len(flag.Args()) > 1 {
-		fmt.Println()
-		fmt.Printf("[ERROR] can only scan one top directory: got %d", len(args))
-		fmt.Println()
-		fmt.Println()
-		fmt.Println("[TIP] Use double-quotes around the directory path if it contains spaces.")
-		fmt.Println("[TIP] Example: tdu.exe \"C:\\Program Files\"")
-		fmt.Println()
-		flag.Usage()
+	if (sc.loadPath != "" || sc.importPath != "" || sc.archivePath != "") && (len(args) > 0 || *ex != "" || *mn != "" || sc.savePath != "" ||
+		sc.allMounts || sc.watch || sc.compare || sc.enableDelete ||
+		sc.olderThan > 0 || sc.newerThan > 0 || sc.shared != nil) {
+		fmt.Print(tr("\n  [ERROR] --load, --import and --archive take no directory nor scan option\n\n"))
+		os.Exit(2)
+	}
+	if sc.enableDelete && sc.plain { // the biggest files are not numbered
+		fmt.Print(tr("\n  [ERROR] --enable-delete cannot be used with --plain\n\n"))
+		os.Exit(2)
+	}
+	if sc.savePath != "" && (sc.allMounts || sc.compare) {
+		fmt.Print(tr("\n  [ERROR] --save needs a single directory\n\n"))
+		os.Exit(2)
+	}
+	if sc.watch && (runtime.GOOS != "linux" || sc.allMounts || sc.reporter != nil || sc.duOutput || sc.compare ||
+		len(args) > 1 || sc.estimate > 0) {
+		fmt.Print(tr("\n  [ERROR] --watch only works on Linux, with a single directory, no --format nor --estimate\n\n"))
+		os.Exit(2)
+	}
+	if sc.historyCmd {
+		if len(args) > 1 || sc.historyRuns < 1 {
+			fmt.Print(tr("\n  [ERROR] history needs at most one directory\n\n"))
+			os.Exit(2)
+		}
+		return args
+	}
+	if sc.compare {
+		if len(args) != 2 || *ex != "" || sc.allMounts {
+			fmt.Print(tr("\n  [ERROR] compare needs two directories and no export file\n\n"))
+			flag.Usage()
+			os.Exit(2)
+		}
+		return args
+	}
+	if len(args) > 1 && (*ex != "" || *mn != "" || sc.savePath != "" || sc.reporter != nil || sc.dotPath != "" ||
+		sc.foldedPath != "") {
+		fmt.Print(tr("\n  [ERROR] Several directories cannot be scanned with an export, manifest, snapshot, graph or --format\n\n"))
 		os.Exit(2)
 	}
 	return args
@@ -761,22 +2214,80 @@ func usage(sc *s_scan) []string {
 
 func showElapsed(sc *s_scan) {
 	elapsed := time.Since(sc.start)
-	fmt.Printf("\n  Total time: %.3f s\n\n", elapsed.Seconds())
+	fmt.Fprintf(sc.out, "\n"+tr("  Total time: %.3f s\n\n"), elapsed.Seconds())
+}
+
+/* Scan rate, and an estimate of the remaining time when the number of
+ * items to scan is known, e.g. "45k/s, ~38% done, ETA 1m20s" */
+func progressRate(sc *s_scan) string {
+	secs := time.Since(sc.scanStart).Seconds()
+	if secs < 0.2 {
+		return ""
+	}
+	rate := float64(sc.nItems) / secs
+	s := fmt.Sprintf(tr(" items, %s/s"), shortCount(int64(rate)))
+	if sc.expectItems <= 0 || sc.nItems >= sc.expectItems || rate <= 0 {
+		return s
+	}
+	left := time.Duration(float64(sc.expectItems-sc.nItems)/rate) * time.Second
+	return s + fmt.Sprintf(tr(", ~%d%% done, ETA %s"), sc.nItems*100/sc.expectItems,
+		left.Round(time.Second))
+}
+
+/* Directory being scanned, truncated to the columns left after used */
+func progressPath(sc *s_scan, used int) string {
+	room := sc.progressCols - used - 1
+	if sc.scanning == "" || room < 10 {
+		return ""
+	}
+	return " " + fmt.Sprintf("%-*s", room, smartTruncate(sc.scanning, room))
+}
+
+/* Text after the counter of the progress line */
+func progressInfo(sc *s_scan) string {
+	s := progressRate(sc) + "  ....]"
+	used := utf8.RuneCountInString(tr("  [.... scanning... ")) + 6 + utf8.RuneCountInString(s)
+	return s + progressPath(sc, used)
+}
+
+func shortCount(n int64) string { // 45k rather than 45123
+	switch {
+	case n >= 10000000:
+		return fmt.Sprintf("%dM", n/1000000)
+	case n >= 10000:
+		return fmt.Sprintf("%dk", n/1000)
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+func progressWidth(sc *s_scan) int {
+	if w := getTtyWidth(sc) - 1; w > 0 {
+		return w
+	}
+	return cst_PROGRESSWIDTH
 }
 
 func showProgress(sc *s_scan) {
 	var i int
 	var m string
-	space := strings.Repeat(" ", 42)
-	fmt.Println()
+	sc.progressCols = progressWidth(sc)
+	space := strings.Repeat(" ", sc.progressCols)
+	fmt.Fprintln(sc.con)
 	for {
 		time.Sleep(time.Duration(sc.refreshDelay) * time.Millisecond)
 		select {
+		case <-sc.resize: // do not wrap the progress line on small terminals
+			fmt.Fprint(sc.con, space+"\r")
+			sc.progressCols = progressWidth(sc)
+			space = strings.Repeat(" ", sc.progressCols)
+		case d := <-sc.curDir:
+			sc.scanning = d
+			printProgress(sc)
 		case m = <-sc.msg:
-			fmt.Print(space)
-			fmt.Print("\r")
+			fmt.Fprint(sc.con, space)
+			fmt.Fprint(sc.con, "\r")
 			if m != cst_ENDPROGRESS {
-				fmt.Println(m)
+				fmt.Fprintln(sc.con, m)
 			}
 		default:
 			i++
@@ -791,78 +2302,377 @@ func showProgress(sc *s_scan) {
 }
 
 func endProgress(sc *s_scan) {
-	if sc.tty {
+	if sc.progressJSON != nil || sc.progress && !sc.quiet {
 		sc.msg <- cst_ENDPROGRESS
 		<-sc.done
 	}
 }
 
 func push(sc *s_scan, msg string) {
+	if sc.progressJSON == nil && (!sc.progress || sc.quiet) {
+		return // no progress display to consume messages
+	}
 	sc.msg <- msg
 }
 
-func showTitle() {
+func showTitle(sc *s_scan) {
 	spc := strings.Repeat("=", 11)
-	fmt.Println()
-	fmt.Printf("%s Top Disk Usage v%s (GNU GPL) %s", spc, prg_VERSION, spc)
-	fmt.Println()
-	fmt.Println()
+	fmt.Fprintln(sc.info)
+	fmt.Fprintf(sc.info, "%s Top Disk Usage v%s (GNU GPL) %s", spc, prg_VERSION, spc)
+	fmt.Fprintln(sc.info)
+	fmt.Fprintln(sc.info)
 }
 
 func relocate(sc *s_scan, args []string) string {
-	d, err := changeDir(flag.Args())
+	d, err := changeDir(args)
 	if err != nil {
-		showTitle()
-		fmt.Println(err)
-		fmt.Println()
+		showTitle(sc)
+		fmt.Fprintln(sc.out, err)
+		fmt.Fprintln(sc.out)
 		os.Exit(2)
 	}
 	return d
 }
 
 func showResults(sc *s_scan, fi []file, total *file) {
+	writeDot(sc, fi, total)
+	writeFolded(sc, fi, total)
+	if sc.duOutput {
+		writeDu(sc, fi, total)
+		return
+	}
+	if sc.reporter != nil {
+		err := sc.reporter.write(sc, reportRows(sc, fi), total)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, tr("  [ERROR] Cannot write report: %v\n"), err)
+		}
+		return
+	}
 	show(sc, fi, total) // Step 3
 	showmax(sc, total)  // step 4
+	showestimate(sc, fi, total)
+	showitemdirs(sc)
 	showempty(sc)
+	showemptyfiles(sc)
 	showdenied(sc)
 	showerrors(sc)
+	showlocked(sc)
+	showxattrs(sc)
+	showsparse(sc)
+	showshared(sc)
+	showcompression(sc, fi, total)
+	showinodes(sc, fi, total)
+	showslack(sc, total)
+	showdocker(sc)
+	showgitrepos(sc)
+	showlogs(sc)
+	showaudit(sc)
+	showreclaim(sc)
 	showstreams(sc)
 	showdevices(sc)
+	showotherfs(sc)
+	showsnapshots(sc)
+	showdupmounts(sc)
+	showcachedirs(sc)
+	showgitignored(sc)
+	showpruned(sc)
+	showdevusage(sc, total)
+	showyears(sc, total)
+	showexts(sc, total)
+	showcategories(sc, total)
+	showowners(sc, total)
+	showages(sc)
+}
+
+/* Ctrl+C (or SIGTERM) cancels the scan, which then ends as if a budget
+ * was reached: results and exports cover the items scanned so far. A
+ * second Ctrl+C, or one after the scan, stops the program as usual.
+ * The --timeout deadline cancels the scan the same way. */
+func catchInterrupt(sc *s_scan) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if sc.timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), sc.timeout)
+	}
+	sc.ctx = ctx
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-c:
+			signal.Stop(c)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		cancel()
+	}
 }
 
 func startProgress(sc *s_scan) {
-	if sc.tty {
+	if sc.progressJSON != nil {
+		go showProgressJSON(sc)
+		return
+	}
+	if sc.quiet {
+		return
+	}
+	if sc.progress {
 		go showProgress(sc)
 	} else {
-		fmt.Fprintln(os.Stderr, "  Please wait...")
+		fmt.Fprintln(os.Stderr, tr("  Please wait..."))
 	}
 }
 
+/* Like dd, SIGUSR1 (or Ctrl+T on BSD) prints the counters of the scan
+ * on stderr, without stopping it, when scan() enters the next directory */
+func catchStatus(sc *s_scan) (stop func()) {
+	if len(statusSignals) == 0 || sc.progressJSON == os.Stderr { // see stderrEvents
+		return func() {}
+	}
+	sc.status = make(chan os.Signal, 1)
+	signal.Notify(sc.status, statusSignals...)
+	return func() {
+		signal.Stop(sc.status)
+		sc.status = nil
+	}
+}
+
+func showStatus(sc *s_scan, depth int64, dir string) {
+	fmt.Fprintf(os.Stderr, tr("\n  [STATUS] %d items, %s, %d errors, %d denied, depth %d, %.1f s\n  in %s\n"),
+		sc.nItems, fmtSz(sc, sc.nBytes), sc.nErrors, sc.nDenied, depth,
+		time.Since(sc.scanStart).Seconds(), dir)
+}
+
 /* Basically, the process has got several steps:
  * 1. change directory to given path
  * 2. scan all files recursively, collecting 'stat' data
  * 3. sort results and output a list of biggest items at depth 1.
  * 4. show the largest files at any depth.
  */
+/* Scan and report of d, nil when d itself cannot be read */
+func run(sc *s_scan, d string) *file {
+	sc.root = d
+	if !sc.quiet {
+		fmt.Fprintf(sc.info, "  OS: %s %s,", sc.os, runtime.GOARCH)
+		fmt.Fprintf(sc.info, tr(" scanning [%s]...\n"), d)
+	}
+	ncduInit(sc)
+	sc.dupMounts = dupMounts(d, sc.tagOtherFs)
+	if sc.dockerReport {
+		sc.docker = findContainerStore(d)
+	}
+	if sc.logReport {
+		sc.logs = findLogs(d)
+	}
+	if sc.reclaimReport {
+		sc.reclaim = findReclaimable(d)
+	}
+	gitInit(sc)
+	sc.scanStart = time.Now()
+	startProgress(sc)
+	stop := catchInterrupt(sc)
+	stopStatus := catchStatus(sc)
+	if sc.watch {
+		sc.watcher = newWatcher(sc)
+	}
+	var fi []file
+	t, err := scan(sc, &fi, ".", 1, nil, nil) // Step 2
+	stopStatus()
+	stop()
+	endProgress(sc)
+	if err != nil {
+		fmt.Fprintf(sc.out, tr("  [ERROR] Cannot scan [%s]: %v\n"), d, err)
+		return nil
+	}
+	getConsoleWidth(sc) // the terminal may have been resized meanwhile
+	showResults(sc, fi, t)
+	if sc.ranking != nil {
+		for _, f := range fi {
+			f.fullpath = fullPath(sc, f.path)
+			f.kids = nil
+			*sc.ranking = append(*sc.ranking, f)
+		}
+	}
+	if sc.reporter == nil && !sc.duOutput {
+		offerDelete(sc, &fi, t)
+	}
+	ncduEnd(sc)
+	writeManifest(sc)
+	saveSnapshot(sc)
+	saveHistory(sc, fi, t)
+	if sc.watcher != nil {
+		watch(sc, &fi, t)
+	}
+	return t
+}
+
+/* Scan each mountpoint separately, then summarize them all */
+func runMounts(sc *s_scan) {
+	mounts := listMounts(sc)
+	if len(mounts) == 0 {
+		fmt.Fprintln(sc.out, tr("  [ERROR] No local mountpoint found"))
+		return
+	}
+	var totals, ranking []file
+	sc.ranking = &ranking
+	for _, m := range mounts {
+		d, err := changeDir([]string{m})
+		if err != nil {
+			fmt.Fprintf(sc.out, "  %v\n\n", err)
+			continue
+		}
+		s := cloneScanStruct(sc)
+		t := run(s, d)
+		fmt.Fprintln(sc.out)
+		if t == nil {
+			sc.failed = true
+			continue
+		}
+		t.fullpath = d
+		totals = append(totals, *t)
+		if s.interrupted { // Ctrl+C stops the remaining scans too
+			break
+		}
+	}
+	showtotals(sc, "ALL MOUNTS", totals)
+	showranking(sc, ranking)
+}
+
+/* Largest depth 1 items of all the mountpoints */
+func showranking(sc *s_scan, fi []file) {
+	if len(fi) == 0 {
+		return
+	}
+	sortFiles(sc, fi)
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("LARGEST ITEMS OF ALL MOUNTS"))
+	for i, f := range fi {
+		if i >= sc.maxShownLines {
+			break
+		}
+		du := shownSize(sc, f.diskUsage, f.size)
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\n", du, f.fullpath)
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s| %s\n", i+1, fmtSz(sc, du),
+			smartTruncate(f.fullpath, sc.maxNameLen+18))
+	}
+}
+
+/* Scan each directory given on the command line, then compare them */
+func runRoots(sc *s_scan, args []string) {
+	var totals []file
+	for i, a := range args { // relative to the start directory
+		if abs, err := filepath.Abs(a); err == nil {
+			args[i] = abs
+		}
+	}
+	for _, a := range args {
+		d, err := changeDir([]string{a})
+		if err != nil {
+			fmt.Fprintf(sc.out, "  %v\n", err)
+			fmt.Fprintln(sc.out, tr("[TIP] Use double-quotes around the directory path if it contains spaces."))
+			fmt.Fprintln(sc.out, tr("[TIP] Example: tdu.exe \"C:\\Program Files\""))
+			fmt.Fprintln(sc.out)
+			continue
+		}
+		s := cloneScanStruct(sc)
+		t := run(s, d)
+		fmt.Fprintln(sc.out)
+		if t == nil {
+			sc.failed = true
+			continue
+		}
+		t.fullpath = d
+		totals = append(totals, *t)
+		if s.interrupted { // Ctrl+C stops the remaining scans too
+			break
+		}
+	}
+	sortFiles(sc, totals)
+	showtotals(sc, "ALL DIRECTORIES", totals)
+}
+
+func showtotals(sc *s_scan, title string, totals []file) {
+	var du, items int64
+	fmt.Fprintln(sc.out, header(title))
+	for i, t := range totals {
+		i++
+		du += t.diskUsage
+		items += t.items
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\n", t.diskUsage, t.fullpath)
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s|%10d "+tr("items")+"| %s\n", i, fmtSz(sc, t.diskUsage),
+			t.items, t.fullpath)
+	}
+	if sc.plain {
+		fmt.Fprintf(sc.out, "%d\ttotal\n", du)
+		return
+	}
+	fmt.Fprintf(sc.out, "    %12s|%10d "+tr("items")+"| "+tr("TOTAL")+"\n", fmtSz(sc, du), items)
+}
+
 func main() {
 	_, sys := osInit()
+	initLang()
 	start := time.Now()
 	sc := newScanStruct(start, sys)
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		sc.compare = true
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	} else if len(os.Args) > 1 && os.Args[1] == "history" {
+		sc.historyCmd = true
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
 	args := usage(sc)
-	d := relocate(sc, args) // step 1
+	var d string
+	if !sc.allMounts && !sc.compare && !sc.historyCmd && sc.loadPath == "" && sc.importPath == "" &&
+		sc.archivePath == "" && !sc.mountList && len(args) < 2 {
+		d = relocate(sc, args) // step 1
+	}
 	detectOS(sc)
 	initTty(sc)
+	stderrEvents(sc)
+	if !sc.tty && !sc.plainSet {
+		sc.plain = true
+	}
 	getConsoleWidth(sc)
-	showTitle()
-	fmt.Printf("  OS: %s %s,", sc.os, runtime.GOARCH)
-	fmt.Printf(" scanning [%s]...\n", d)
-	ncduInit(sc)
-	startProgress(sc)
-	var fi []file
-	t, _ := scan(sc, &fi, ".", 1) // Step 2
-	endProgress(sc)
-	showResults(sc, fi, t)
-	ncduEnd(sc)
-	showElapsed(sc)
+	watchResize(sc)
+	if !sc.quiet {
+		showTitle(sc)
+	}
+	if sc.historyCmd {
+		showHistory(sc, args)
+	} else if sc.compare {
+		compareDirs(sc, args)
+	} else if sc.mountList {
+		showMountList(sc)
+	} else if sc.allMounts {
+		runMounts(sc)
+	} else if len(args) > 1 {
+		runRoots(sc, args)
+	} else if sc.loadPath != "" {
+		load(sc)
+	} else if sc.importPath != "" {
+		importNcdu(sc)
+	} else if sc.archivePath != "" {
+		showArchive(sc)
+	} else if run(sc, d) == nil {
+		sc.failed = true
+	}
+	if !sc.quiet && !sc.deterministic {
+		showElapsed(sc)
+	}
+	if sc.report != nil {
+		sc.report.Close()
+	}
 	osEnd(sys)
+	if sc.failed {
+		os.Exit(1)
+	}
 }
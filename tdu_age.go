@@ -0,0 +1,126 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Disk usage by age of the last modification (--age), and the largest
+ * files not modified for more than a year: candidates for archiving. */
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cst_DAY = 24 * time.Hour
+
+var ageBuckets = []struct {
+	label string
+	max   time.Duration // 0: no limit
+}{
+	{"< 30 days", 30 * cst_DAY},
+	{"< 1 year", 365 * cst_DAY},
+	{"< 3 years", 3 * 365 * cst_DAY},
+	{"> 3 years", 0},
+}
+
+/* Parses ages such as 90m, 12h, 7d, 2w, 1y (a year being 365 days) */
+func parseAge(s string) (time.Duration, error) {
+	units := map[byte]time.Duration{'m': time.Minute, 'h': time.Hour,
+		'd': cst_DAY, 'w': 7 * cst_DAY, 'y': 365 * cst_DAY}
+	l := len(s)
+	if l < 2 || units[s[l-1]] == 0 {
+		return 0, fmt.Errorf("invalid age: %q", s)
+	}
+	v, err := strconv.ParseFloat(s[:l-1], 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid age: %q", s)
+	}
+	return time.Duration(v * float64(units[s[l-1]])), nil
+}
+
+/* Tells whether a file is listed with --older-than and --newer-than */
+func matchAge(sc *s_scan, f *file) bool {
+	age := sc.start.Sub(f.mtime)
+	if sc.olderThan > 0 && age < sc.olderThan {
+		return false
+	}
+	if sc.newerThan > 0 && age >= sc.newerThan {
+		return false
+	}
+	return true
+}
+
+func countAge(sc *s_scan, f *file) {
+	if sc.ages == nil || f.mtime.IsZero() {
+		return
+	}
+	age := sc.start.Sub(f.mtime)
+	for i, b := range ageBuckets {
+		if b.max == 0 || age < b.max {
+			sc.ages[i] += shownSize(sc, f.diskUsage, f.size)
+			break
+		}
+	}
+	if age < 365*cst_DAY || !f.isRegular || !matchAge(sc, f) {
+		return
+	}
+	if len(sc.coldfiles) > sc.maxBigFiles*4 {
+		sortFiles(sc, sc.coldfiles)
+		sc.coldfiles = sc.coldfiles[0:sc.maxBigFiles]
+	}
+	sc.coldfiles = append(sc.coldfiles, *f)
+}
+
+func showages(sc *s_scan) {
+	var max int64
+	for _, du := range sc.ages {
+		if du > max {
+			max = du
+		}
+	}
+	if max == 0 {
+		return
+	}
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("USAGE BY AGE"))
+	for i, b := range ageBuckets {
+		du := sc.ages[i]
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\n", du, b.label)
+			continue
+		}
+		n := int(du * cst_BARWIDTH / max)
+		if n == 0 && du > 0 {
+			n = 1
+		}
+		fmt.Fprintf(sc.out, "  %-10s|%12s| %s\n", tr(b.label), fmtSz(sc, du),
+			strings.Repeat(bar_CHAR, n))
+	}
+	if len(sc.coldfiles) == 0 || sc.maxBigFiles <= 0 {
+		return
+	}
+	sortFiles(sc, sc.coldfiles)
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("COLD FILES (1 YEAR+)"))
+	for i, f := range sc.coldfiles {
+		if i >= sc.maxBigFiles {
+			break
+		}
+		du := shownSize(sc, f.diskUsage, f.size)
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\n", du, fullPath(sc, f.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s| %s | %s\n", i+1, fmtSz(sc, du),
+			f.mtime.Format("2006-01-02"), smartTruncate(f.path, sc.maxNameLen+5))
+	}
+}
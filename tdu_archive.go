@@ -0,0 +1,161 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Archives (--archive) are shown as if they had been extracted and
+ * scanned. Their items are read into a tree of nodes, like an Ncdu
+ * export (see replay): zip files through their io/fs.FS, as any other
+ * fs.FS would be, and tar files from the headers of their items.
+ * The disk usage of an item is the space it takes in the archive. */
+
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const tar_BLOCK = 512 // tar headers and contents are padded to blocks
+
+/* Tree of the items of an fs.FS below dir */
+func fsNode(fsys fs.FS, dir, name string) *node {
+	n := &node{Name: name, IsDir: true}
+	es, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		n.ReadError = true
+		return n
+	}
+	for _, e := range es {
+		if e.IsDir() {
+			n.Children = append(n.Children, fsNode(fsys, path.Join(dir, e.Name()), e.Name()))
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			n.Children = append(n.Children, &node{Name: e.Name(), ReadError: true})
+			continue
+		}
+		c := &node{Name: fi.Name(), Size: fi.Size(), DiskUsage: fi.Size(),
+			Symlink: fi.Mode()&fs.ModeSymlink != 0, NotReg: !fi.Mode().IsRegular()}
+		if h, ok := fi.Sys().(*zip.FileHeader); ok {
+			c.DiskUsage = int64(h.CompressedSize64)
+		}
+		n.Children = append(n.Children, c)
+	}
+	return n
+}
+
+/* Tree of the items of a tar stream. Parent directories are not always
+ * stored before their content, or at all. */
+func tarNode(r io.Reader, name string) (*node, error) {
+	root := &node{Name: name, IsDir: true}
+	dirs := map[string]*node{".": root}
+	var dirOf func(p string) *node
+	dirOf = func(p string) *node {
+		if d, ok := dirs[p]; ok {
+			return d
+		}
+		d := &node{Name: path.Base(p), IsDir: true}
+		parent := dirOf(path.Dir(p))
+		parent.Children = append(parent.Children, d)
+		dirs[p] = d
+		return d
+	}
+	t := tar.NewReader(r)
+	for {
+		h, err := t.Next()
+		if err == io.EOF {
+			return root, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		p := path.Clean(strings.TrimLeft(h.Name, "/"))
+		if p == "." {
+			continue
+		}
+		if h.Typeflag == tar.TypeDir {
+			dirOf(p).DiskUsage = tar_BLOCK
+			continue
+		}
+		n := &node{Name: path.Base(p), Size: h.Size,
+			DiskUsage: tar_BLOCK + (h.Size+tar_BLOCK-1)/tar_BLOCK*tar_BLOCK}
+		switch h.Typeflag {
+		case tar.TypeReg, tar.TypeLink, tar.TypeGNUSparse:
+		case tar.TypeSymlink:
+			n.Symlink = true
+		default:
+			n.NotReg = true
+		}
+		parent := dirOf(path.Dir(p))
+		parent.Children = append(parent.Children, n)
+	}
+}
+
+/* Reads a zip, tar, tar.gz or tar.bz2 archive, found from its content */
+func readArchive(name string) (*node, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	base := filepath.Base(name)
+	b := bufio.NewReader(f)
+	magic, _ := b.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		st, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		z, err := zip.NewReader(f, st.Size())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		return fsNode(z, ".", base), nil
+	case bytes.HasPrefix(magic, []byte("\x1f\x8b")):
+		z, err := gzip.NewReader(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		return tarNode(z, base)
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return tarNode(bzip2.NewReader(b), base)
+	}
+	n, err := tarNode(b, base)
+	if err != nil {
+		return nil, fmt.Errorf("%s: not a zip or tar archive", name)
+	}
+	return n, nil
+}
+
+/* Shows which paths take the most space in an archive */
+func showArchive(sc *s_scan) {
+	root, err := readArchive(sc.archivePath)
+	if err != nil {
+		fmt.Fprintf(sc.out, tr("  [ERROR] Cannot read archive: %v\n"), err)
+		return
+	}
+	if !sc.quiet {
+		fmt.Fprintf(sc.out, tr("  Content of archive %s\n\n"), sc.archivePath)
+	}
+	fi, t := replay(sc, root)
+	showResults(sc, fi, t)
+}
@@ -0,0 +1,125 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Security audit of the scanned files (--audit), from the stats already
+ * done by the scan: the largest world-writable files, the setuid and
+ * setgid files, and the usage of files whose owner no longer exists.
+ * Owners are not known on Windows. */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+type audit_report struct {
+	writable []file // largest world-writable files
+	setid    []file // setuid and setgid files
+	modes    map[string]os.FileMode
+	orphans  map[uint32]*owner_usage // files of deleted users
+}
+
+func countAudit(sc *s_scan, f *file) {
+	a := sc.audit
+	if a == nil || f.fi == nil || !f.isRegular {
+		return
+	}
+	uid, _, ok := fileOwner(f)
+	if !ok {
+		return
+	}
+	m := f.fi.Mode()
+	if m.Perm()&0002 != 0 {
+		if len(a.writable) > sc.maxShownLines*4 {
+			sortFiles(sc, a.writable)
+			a.writable = a.writable[:sc.maxShownLines]
+		}
+		a.writable = append(a.writable, *f)
+	}
+	if m&(os.ModeSetuid|os.ModeSetgid) != 0 {
+		a.setid = append(a.setid, *f)
+		a.modes[f.path] = m
+	}
+	// an unknown uid cannot be told from a slow lookup, see userName
+	if userName(uid) == strconv.FormatUint(uint64(uid), 10) && !nssSlow {
+		addOwner(a.orphans, uid, f)
+	}
+}
+
+/* Permissions as printed by ls, e.g. -rwsr-xr-x */
+func lsMode(m os.FileMode) string {
+	b := []byte(m.Perm().String())
+	set := func(i int) {
+		if b[i] == 'x' {
+			b[i] = 's'
+		} else {
+			b[i] = 'S'
+		}
+	}
+	if m&os.ModeSetuid != 0 {
+		set(3)
+	}
+	if m&os.ModeSetgid != 0 {
+		set(6)
+	}
+	return string(b)
+}
+
+func showAuditList(sc *s_scan, title string, l []file, mode func(*file) string) {
+	if len(l) == 0 {
+		return
+	}
+	sortFiles(sc, l)
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header(title))
+	for i, f := range l {
+		if i >= sc.maxShownLines {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\t%s\n", f.diskUsage, mode(&f), fullPath(sc, f.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s| %-10s| %s\n", i+1, fmtSz(sc, f.diskUsage), mode(&f),
+			smartTruncate(f.path, sc.maxNameLen+6))
+	}
+}
+
+func showaudit(sc *s_scan) {
+	a := sc.audit
+	if a == nil {
+		return
+	}
+	showAuditList(sc, "WORLD-WRITABLE FILES", a.writable, func(*file) string { return "o+w" })
+	showAuditList(sc, "SETUID AND SETGID FILES", a.setid, func(f *file) string {
+		return lsMode(a.modes[f.path])
+	})
+	if len(a.orphans) == 0 {
+		return
+	}
+	var list []*owner_usage
+	for _, u := range a.orphans {
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].diskUsage > list[j].diskUsage })
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("FILES OF DELETED USERS"))
+	for i, u := range list {
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%d\n", u.diskUsage, u.count, u.id)
+			continue
+		}
+		fmt.Fprintf(sc.out, tr("%3d. uid %-8d|%12s|%9d files\n"), i+1, u.id,
+			fmtSz(sc, u.diskUsage), u.count)
+	}
+}
@@ -13,9 +13,64 @@
 package main
 
 import (
+	"os"
 	"syscall"
 )
 
 func tcgets() uintptr {
 	return uintptr(syscall.TIOCGETA)
 }
+
+// Ctrl+T sends SIGINFO to the foreground process
+var statusSignals = []os.Signal{syscall.SIGUSR1, syscall.SIGINFO}
+
+func statFs(path string, st *fs_stat) error {
+	var s syscall.Statfs_t
+	if err := syscall.Statfs(path, &s); err != nil {
+		return err
+	}
+	*st = fs_stat{int64(s.Type), int64(s.Flags), uint64(s.Bsize), s.Blocks,
+		s.Bfree, uint64(s.Bavail), s.Files, uint64(s.Ffree)}
+	return nil
+}
+
+func fsMounts() []mount_entry {
+	return statMounts(syscall.Getfsstat, func(st *syscall.Statfs_t) mount_entry {
+		return mount_entry{device: cstr(st.Mntfromname[:]), dir: cstr(st.Mntonname[:]),
+			fsType: cstr(st.Fstypename[:])}
+	})
+}
+
+// Extended attributes are not read on this OS
+func getXattr(path, name string) (string, bool) {
+	return "", false
+}
+
+func xattrSize(path string) int64 {
+	return 0
+}
+
+// Filesystem events are only watched on Linux, see usage()
+func newWatcher(sc *s_scan) scan_watcher { return nil }
+
+func watch(sc *s_scan, fi *[]file, t *file) {}
+
+// Entries of a directory opened in a Root are stat'ed by ReadDir (fstatat)
+func dirEntries(d *os.File) ([]os.DirEntry, error) {
+	return d.ReadDir(-1)
+}
+
+// Shared extents are only read on Linux, see --shared
+func fileExtents(path string) (int64, int64, bool) {
+	return 0, 0, false
+}
+
+// Quotas are only read on Linux
+func getQuotas(device string) []quota_usage {
+	return nil
+}
+
+// Bind mounts are only detected on Linux, from /proc/self/mountinfo
+func dupMounts(root string, crossFs bool) map[string]string {
+	return nil
+}
@@ -0,0 +1,149 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Side-by-side comparison of two directories (tdu compare DIR1 DIR2) */
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+type cmp_entry struct {
+	name        string
+	left, right *file
+}
+
+func (e *cmp_entry) diff() int64 {
+	var d int64
+	if e.left != nil {
+		d -= e.left.diskUsage
+	}
+	if e.right != nil {
+		d += e.right.diskUsage
+	}
+	return d
+}
+
+/* Scans dir without printing the usual report */
+func scanOnly(sc *s_scan, dir string) (*s_scan, []file, *file, error) {
+	d, err := changeDir([]string{dir})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	s := cloneScanStruct(sc)
+	s.root = d
+	s.scanStart = time.Now()
+	fmt.Fprintf(s.info, tr("  Scanning [%s]...\n"), d)
+	startProgress(s)
+	var fi []file
+	t, err := scan(s, &fi, ".", 1, nil, nil)
+	endProgress(s)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return s, fi, t, nil
+}
+
+func fmtDiff(sc *s_scan, d int64) string {
+	switch {
+	case d > 0:
+		return "+" + fmtSz(sc, d)
+	case d < 0:
+		return "-" + fmtSz(sc, -d)
+	}
+	return "="
+}
+
+func compareDirs(sc *s_scan, dirs []string) {
+	var left, right []file
+	var lt, rt *file
+	for i, d := range dirs {
+		abs, err := filepath.Abs(d)
+		if err == nil {
+			dirs[i] = abs // relative to the starting directory
+		}
+	}
+	s, fi, t, err := scanOnly(sc, dirs[0])
+	if err != nil {
+		fmt.Fprintf(sc.out, "  %v\n\n", err)
+		sc.failed = true
+		return
+	}
+	dirs[0], left, lt = s.root, fi, t
+	s, fi, t, err = scanOnly(sc, dirs[1])
+	if err != nil {
+		fmt.Fprintf(sc.out, "  %v\n\n", err)
+		sc.failed = true
+		return
+	}
+	dirs[1], right, rt = s.root, fi, t
+	byName := make(map[string]*cmp_entry)
+	var entries []*cmp_entry
+	get := func(name string) *cmp_entry {
+		e, ok := byName[name]
+		if !ok {
+			e = &cmp_entry{name: name}
+			byName[name] = e
+			entries = append(entries, e)
+		}
+		return e
+	}
+	for i := range left {
+		get(left[i].name).left = &left[i]
+	}
+	for i := range right {
+		get(right[i].name).right = &right[i]
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].diff(), entries[j].diff()
+		if a < 0 {
+			a = -a
+		}
+		if b < 0 {
+			b = -b
+		}
+		return a > b
+	})
+	showCompare(sc, dirs, entries, lt, rt)
+}
+
+func showCompare(sc *s_scan, dirs []string, entries []*cmp_entry, lt, rt *file) {
+	size := func(f *file) string {
+		if f == nil {
+			return "-"
+		}
+		return fmtSz(sc, f.diskUsage)
+	}
+	fmt.Fprintln(sc.out)
+	fmt.Fprintf(sc.out, tr("  Left : %s\n"), dirs[0])
+	fmt.Fprintf(sc.out, tr("  Right: %s\n"), dirs[1])
+	fmt.Fprintln(sc.out)
+	fmt.Fprintf(sc.out, "    %12s|%12s|%12s| %s\n", tr("Left"), tr("Right"), tr("Diff"), tr("Name"))
+	for i, e := range entries {
+		if i >= sc.maxShownLines {
+			fmt.Fprintf(sc.out, tr("    (%d more)\n"), len(entries)-i)
+			break
+		}
+		name := e.name
+		if (e.left != nil && e.left.isDir) || (e.right != nil && e.right.isDir) {
+			name += "/"
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s|%12s|%12s| %s\n", i+1, size(e.left),
+			size(e.right), fmtDiff(sc, e.diff()), name)
+	}
+	fmt.Fprintf(sc.out, "    %12s|%12s|%12s| "+tr("TOTAL")+"\n", size(lt), size(rt),
+		fmtDiff(sc, rt.diskUsage-lt.diskUsage))
+	fmt.Fprintf(sc.out, "    %12d|%12d|%12d| "+tr("items")+"\n", lt.items, rt.items,
+		rt.items-lt.items)
+}
@@ -0,0 +1,60 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Compression ratio of each depth 1 item (--compression): on btrfs or ZFS
+ * with compression, the allocated blocks are far fewer than the apparent
+ * size. Sparse files and small files packed in metadata count too. */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+func ratio(size, du int64) string {
+	if du <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("x%.2f", float64(size)/float64(du))
+}
+
+func showcompression(sc *s_scan, fi []file, total *file) {
+	if !sc.compression || total.size == 0 {
+		return
+	}
+	list := make([]file, len(fi))
+	copy(list, fi)
+	sort.Slice(list, func(i, j int) bool { // most saved space first
+		return list[i].size-list[i].diskUsage > list[j].size-list[j].diskUsage
+	})
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("COMPRESSION"))
+	if !sc.plain {
+		fmt.Fprint(sc.out, tr("      Disk usage|    Apparent| Ratio|\n"))
+	}
+	for i, f := range list {
+		if i >= sc.maxShownLines {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%s\n", f.diskUsage, f.size, fullPath(sc, f.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s|%12s|%6s| %s\n", i+1, fmtSz(sc, f.diskUsage),
+			fmtSz(sc, f.size), ratio(f.size, f.diskUsage),
+			smartTruncate(f.path, sc.maxNameLen))
+	}
+	if sc.plain {
+		return
+	}
+	fmt.Fprintf(sc.out, tr("  =%13s|%12s|%6s| in total\n"), fmtSz(sc, total.diskUsage),
+		fmtSz(sc, total.size), ratio(total.size, total.diskUsage))
+}
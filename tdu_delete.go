@@ -0,0 +1,69 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Deletion of the biggest files from the console (--enable-delete).
+ * Every deletion must be confirmed, nothing is deleted without a tty.
+ * The table and the biggest files are then printed again. */
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func ask(sc *s_scan, in *bufio.Reader, question string) string {
+	fmt.Fprint(sc.con, question)
+	l, _ := in.ReadString('\n')
+	return strings.TrimSpace(l)
+}
+
+func offerDelete(sc *s_scan, fi *[]file, total *file) {
+	if !sc.enableDelete || !sc.tty || sc.maxBigFiles <= 0 {
+		return
+	}
+	in := bufio.NewReader(os.Stdin)
+	fmt.Fprintln(sc.con)
+	for len(sc.bigfiles) > 0 {
+		a := ask(sc, in, tr("  Number of the file to delete (Enter to quit): "))
+		n, err := strconv.Atoi(a)
+		if err != nil || n < 1 || n > sc.maxBigFiles || n > len(sc.bigfiles) {
+			return
+		}
+		f := sc.bigfiles[n-1]
+		p := fullPath(sc, f.path)
+		q := fmt.Sprintf(tr("  Delete %s (%s)? [y/N] "), p, fmtSz(sc, f.diskUsage))
+		if a = ask(sc, in, q); a != "y" && a != "Y" {
+			continue
+		}
+		if err := os.Remove(p); err != nil {
+			fmt.Fprintf(sc.out, "  %v\n", err)
+			continue
+		}
+		sc.bigfiles = append(sc.bigfiles[:n-1], sc.bigfiles[n:]...)
+		sc.nItems--
+		sc.nFiles--
+		if f.nLinks > 1 { // the data is still used by the other links
+			apply(fi, total, f.path, 0, -f.size, -1)
+			fmt.Fprintln(sc.out, tr("  Deleted, but other hard links remain: no space freed."))
+			continue
+		}
+		apply(fi, total, f.path, -f.diskUsage, -f.size, -1)
+		fmt.Fprintf(sc.out, tr("  Freed %s, disk space is now %s\n"), fmtSz(sc, f.diskUsage),
+			fmtSz(sc, total.diskUsage))
+		fmt.Fprintln(sc.out)
+		show(sc, *fi, total)
+		showmax(sc, total)
+		fmt.Fprintln(sc.out)
+	}
+}
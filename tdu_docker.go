@@ -0,0 +1,327 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Usage of container images, containers and volumes (--docker).
+ * Docker and Podman keep them in directories named after hashes: the
+ * usage of each of these directories is summed during the scan, then
+ * named from the metadata of the storage driver. Layers used by several
+ * images are shown apart, so that nothing is counted twice. */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type container_store struct {
+	engine string           // docker or podman
+	dir    string           // absolute path of the storage
+	rel    string           // path relative to the scanned directory
+	usage  map[string]int64 // usage of each directory, e.g. "overlay2/<id>"
+}
+
+type store_item struct {
+	kind string // image, container, volume
+	name string
+	keys []string // directories of the item, see container_store.usage
+}
+
+var containerStores = []struct{ engine, dir string }{
+	{"docker", "/var/lib/docker"},
+	{"podman", "/var/lib/containers/storage"},
+}
+
+/* Areas of the storage holding one directory per layer, container or volume */
+var storeAreas = map[string]bool{"overlay2": true, "containers": true,
+	"volumes": true, "overlay": true, "overlay-containers": true}
+
+/* Storage of Docker or Podman, if it lies in the scanned directory */
+func findContainerStore(root string) *container_store {
+	stores := containerStores
+	if h, err := os.UserHomeDir(); err == nil { // rootless Podman
+		stores = append(stores, struct{ engine, dir string }{"podman",
+			filepath.Join(h, ".local/share/containers/storage")})
+	}
+	for _, s := range stores {
+		rel, err := filepath.Rel(root, s.dir)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if _, err := os.Stat(s.dir); err != nil {
+			continue
+		}
+		if rel == "." {
+			rel = ""
+		}
+		return &container_store{engine: s.engine, dir: s.dir, rel: rel,
+			usage: make(map[string]int64)}
+	}
+	return nil
+}
+
+func countDocker(sc *s_scan, f *file) {
+	d := sc.docker
+	if d == nil || f.diskUsage == 0 {
+		return
+	}
+	p := f.path
+	if d.rel != "" {
+		if !strings.HasPrefix(p, d.rel+sc.pathSeparator) {
+			return
+		}
+		p = p[len(d.rel)+1:]
+	}
+	i := strings.Index(p, sc.pathSeparator)
+	if i < 0 || !storeAreas[p[:i]] {
+		return
+	}
+	if j := strings.Index(p[i+1:], sc.pathSeparator); j >= 0 {
+		p = p[:i+1+j]
+	}
+	d.usage[filepath.ToSlash(p)] += f.diskUsage
+}
+
+func readJSON(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func readID(path string) string {
+	b, _ := ioutil.ReadFile(path)
+	return strings.TrimSpace(string(b))
+}
+
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+/* Images, containers and volumes of Docker with the overlay2 driver */
+func dockerItems(dir string) ([]store_item, []store_item) {
+	idb := filepath.Join(dir, "image", "overlay2")
+	var repos struct {
+		Repositories map[string]map[string]string
+	}
+	readJSON(filepath.Join(idb, "repositories.json"), &repos)
+	names := make(map[string]string) // image id -> first tag
+	for _, tags := range repos.Repositories {
+		for tag, id := range tags {
+			if strings.Contains(tag, "@") {
+				continue // digest
+			}
+			if n, ok := names[id]; !ok || tag < n {
+				names[id] = tag
+			}
+		}
+	}
+	var images, others []store_item
+	ids, _ := ioutil.ReadDir(filepath.Join(idb, "imagedb", "content", "sha256"))
+	for _, fi := range ids {
+		var img struct {
+			RootFS struct {
+				DiffIDs []string `json:"diff_ids"`
+			} `json:"rootfs"`
+		}
+		if readJSON(filepath.Join(idb, "imagedb", "content", "sha256", fi.Name()), &img) != nil {
+			continue
+		}
+		id := "sha256:" + fi.Name()
+		it := store_item{kind: "image", name: names[id]}
+		if it.name == "" {
+			it.name = shortID(id)
+		}
+		var chain string // layer ids are chained digests of the diffs
+		for _, diff := range img.RootFS.DiffIDs {
+			if chain == "" {
+				chain = diff
+			} else {
+				chain = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(chain+" "+diff)))
+			}
+			c := readID(filepath.Join(idb, "layerdb", "sha256",
+				strings.TrimPrefix(chain, "sha256:"), "cache-id"))
+			if c != "" {
+				it.keys = append(it.keys, "overlay2/"+c)
+			}
+		}
+		images = append(images, it)
+	}
+	cs, _ := ioutil.ReadDir(filepath.Join(dir, "containers"))
+	for _, fi := range cs {
+		id := fi.Name()
+		var cfg struct{ Name string }
+		readJSON(filepath.Join(dir, "containers", id, "config.v2.json"), &cfg)
+		it := store_item{kind: "container", name: strings.TrimPrefix(cfg.Name, "/"),
+			keys: []string{"containers/" + id}}
+		if it.name == "" {
+			it.name = shortID(id)
+		}
+		mounts := filepath.Join(idb, "layerdb", "mounts", id)
+		for _, m := range []string{"mount-id", "init-id"} {
+			if c := readID(filepath.Join(mounts, m)); c != "" {
+				it.keys = append(it.keys, "overlay2/"+c)
+			}
+		}
+		others = append(others, it)
+	}
+	return images, others
+}
+
+/* Images and containers of Podman (containers/storage) */
+func podmanItems(dir string) ([]store_item, []store_item) {
+	var layers []struct{ ID, Parent string }
+	readJSON(filepath.Join(dir, "overlay-layers", "layers.json"), &layers)
+	parent := make(map[string]string, len(layers))
+	for _, l := range layers {
+		parent[l.ID] = l.Parent
+	}
+	var imgs []struct {
+		ID    string
+		Names []string
+		Layer string
+	}
+	readJSON(filepath.Join(dir, "overlay-images", "images.json"), &imgs)
+	var images, others []store_item
+	for _, i := range imgs {
+		it := store_item{kind: "image", name: shortID(i.ID)}
+		if len(i.Names) > 0 {
+			it.name = i.Names[0]
+		}
+		for l := i.Layer; l != ""; l = parent[l] {
+			it.keys = append(it.keys, "overlay/"+l)
+		}
+		images = append(images, it)
+	}
+	var ctrs []struct {
+		ID    string
+		Names []string
+		Layer string
+	}
+	readJSON(filepath.Join(dir, "overlay-containers", "containers.json"), &ctrs)
+	for _, c := range ctrs {
+		it := store_item{kind: "container", name: shortID(c.ID),
+			keys: []string{"overlay/" + c.Layer, "overlay-containers/" + c.ID}}
+		if len(c.Names) > 0 {
+			it.name = c.Names[0]
+		}
+		others = append(others, it)
+	}
+	return images, others
+}
+
+/* Items of the storage with their usage, layers shared by several images
+ * and directories of no known item being summed apart */
+func storeUsage(d *container_store) []store_item {
+	list := dockerItems
+	if d.engine == "podman" {
+		list = podmanItems
+	}
+	images, others := list(d.dir)
+	refs := make(map[string]int)
+	for _, i := range images {
+		for _, k := range i.keys {
+			refs[k]++
+		}
+	}
+	for k := range d.usage {
+		if strings.HasPrefix(k, "volumes/") && !strings.HasSuffix(k, ".db") {
+			others = append(others, store_item{kind: "volume",
+				name: strings.TrimPrefix(k, "volumes/"), keys: []string{k}})
+		}
+	}
+	seen := make(map[string]bool)
+	var items []store_item
+	var shared store_item
+	for _, i := range images { // only the layers of this image
+		it := store_item{kind: i.kind, name: i.name}
+		for _, k := range i.keys {
+			if refs[k] > 1 {
+				if !seen[k] {
+					shared.keys = append(shared.keys, k)
+				}
+			} else {
+				it.keys = append(it.keys, k)
+			}
+			seen[k] = true
+		}
+		items = append(items, it)
+	}
+	for _, o := range others {
+		for _, k := range o.keys {
+			seen[k] = true
+		}
+		items = append(items, o)
+	}
+	if len(shared.keys) > 0 {
+		items = append(items, store_item{kind: "image", name: tr("(layers shared by several images)"),
+			keys: shared.keys})
+	}
+	var rest store_item
+	for k := range d.usage {
+		if !seen[k] {
+			rest.keys = append(rest.keys, k)
+		}
+	}
+	if len(rest.keys) > 0 {
+		items = append(items, store_item{kind: "other", name: tr("(unused layers, build cache)"),
+			keys: rest.keys})
+	}
+	return items
+}
+
+func itemUsage(d *container_store, it store_item) int64 {
+	var du int64
+	for _, k := range it.keys {
+		du += d.usage[k]
+	}
+	return du
+}
+
+func showdocker(sc *s_scan) {
+	d := sc.docker
+	if d == nil || len(d.usage) == 0 {
+		return
+	}
+	items := storeUsage(d)
+	sort.Slice(items, func(i, j int) bool {
+		return itemUsage(d, items[i]) > itemUsage(d, items[j])
+	})
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header(strings.ToUpper(d.engine)+" STORAGE"))
+	var total int64
+	for i, it := range items {
+		du := itemUsage(d, it)
+		total += du
+		if i >= sc.maxShownLines {
+			continue
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\t%s\n", du, it.kind, it.name)
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s| %-9s| %s\n", i+1, fmtSz(sc, du), tr(it.kind),
+			smartTruncate(it.name, sc.maxNameLen+8))
+	}
+	if !sc.plain {
+		fmt.Fprintf(sc.out, tr("  =%13s| in %s\n"), fmtSz(sc, total), d.dir)
+	}
+}
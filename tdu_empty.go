@@ -0,0 +1,81 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Zero-byte files and directories full of tiny files (--empty-files),
+ * often left by broken downloads, lock files or caches. */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	tiny_SIZE  = 4096 // smaller files are tiny
+	tiny_FILES = 100  // directories with fewer files are not reported
+)
+
+type tiny_dir struct {
+	path  string
+	files int64 // direct files
+	tiny  int64 // direct files smaller than tiny_SIZE
+}
+
+func countEmptyFile(sc *s_scan, f *file) {
+	if sc.maxEmptyFiles <= 0 || !f.isRegular || f.size > 0 {
+		return
+	}
+	sc.nEmptyFile++
+	if len(sc.emptyfiles) < sc.maxEmptyFiles {
+		sc.emptyfiles = append(sc.emptyfiles, f.path)
+	}
+}
+
+/* Directory whose direct files are nearly all tiny (90%) */
+func countTinyDir(sc *s_scan, path string, files, tiny int64) {
+	if sc.maxEmptyFiles <= 0 || files < tiny_FILES || tiny*10 < files*9 {
+		return
+	}
+	sc.tinydirs = append(sc.tinydirs, tiny_dir{path: path, files: files, tiny: tiny})
+}
+
+func showemptyfiles(sc *s_scan) {
+	if sc.maxEmptyFiles <= 0 {
+		return
+	}
+	if len(sc.emptyfiles) > 0 {
+		fmt.Fprintln(sc.out)
+		fmt.Fprintln(sc.out, header("EMPTY FILES"))
+		for i, p := range sc.emptyfiles {
+			printListItem(sc, i+1, listPath(sc, p))
+		}
+		if !sc.plain {
+			fmt.Fprintf(sc.out, tr("  %d zero-byte files in total\n"), sc.nEmptyFile)
+		}
+	}
+	if len(sc.tinydirs) == 0 {
+		return
+	}
+	sort.Slice(sc.tinydirs, func(i, j int) bool { return sc.tinydirs[i].tiny > sc.tinydirs[j].tiny })
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("DIRECTORIES OF TINY FILES"))
+	for i, d := range sc.tinydirs {
+		if i >= sc.maxEmptyFiles {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%s\n", d.tiny, d.files, fullPath(sc, d.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, tr("%3d.%9d of %9d files under 4 KiB| %s\n"), i+1, d.tiny, d.files,
+			smartTruncate(d.path, sc.maxNameLen))
+	}
+}
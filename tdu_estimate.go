@@ -0,0 +1,155 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Estimation by sampling (--estimate 0.1), for huge network shares. Below
+ * the items of the table, only a random fraction of the subdirectories of
+ * each directory is scanned, and their usage is extrapolated to the others
+ * (two-stage sampling). The variance of each estimate is carried up the
+ * tree, giving a 95% confidence interval of the usage of each item. The
+ * other lists (biggest files...) only cover the directories scanned. */
+
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+/* Directories with few subdirectories are fully scanned: a small sample
+ * of them would miss the large one too often */
+const est_MINDIRS = 10
+
+type est_sample struct {
+	k     int             // subdirectories
+	skip  map[string]bool // subdirectories left out of the sample
+	du    []float64       // disk usage of the scanned subdirectories
+	size  int64
+	items int64
+	vars  float64 // sum of their variances
+}
+
+/* Random sample of the subdirectories of a directory, nil without
+ * --estimate. The items of the table (depth 2) are all scanned. With
+ * --deterministic, the sample only depends on the path of the directory
+ * and the names of its subdirectories. */
+func sampleDirs(sc *s_scan, path string, depth int64, fs []os.DirEntry) *est_sample {
+	if sc.estimate <= 0 {
+		return nil
+	}
+	var dirs []string
+	for _, i := range fs {
+		if i.IsDir() {
+			dirs = append(dirs, i.Name())
+		}
+	}
+	e := &est_sample{k: len(dirs)}
+	m := int(math.Ceil(float64(e.k) * sc.estimate))
+	if m < est_MINDIRS {
+		m = est_MINDIRS
+	}
+	if depth < 2 || m >= e.k {
+		return e
+	}
+	perm := rand.Perm
+	if sc.deterministic {
+		sort.Strings(dirs) // not in the order of the directory
+		h := fnv.New64a()
+		h.Write([]byte(path))
+		perm = rand.New(rand.NewSource(int64(h.Sum64()))).Perm
+	}
+	e.skip = make(map[string]bool, e.k-m)
+	for _, n := range perm(e.k)[m:] {
+		e.skip[dirs[n]] = true
+	}
+	return e
+}
+
+func (e *est_sample) skipped(i os.DirEntry) bool {
+	return e != nil && e.skip[i.Name()]
+}
+
+func (e *est_sample) add(i os.DirEntry, cf *file) {
+	if e == nil || !i.IsDir() {
+		return
+	}
+	e.du = append(e.du, float64(cf.diskUsage))
+	e.size += cf.size
+	e.items += cf.items + 1
+	e.vars += cf.duVar
+}
+
+/* Adds the usage of the subdirectories left out to fo, and the variance
+ * of the estimate */
+func (e *est_sample) extrapolate(sc *s_scan, fo *file) {
+	if e == nil {
+		return
+	}
+	m := len(e.du)
+	sc.estDirs += int64(e.k)
+	sc.estScanned += int64(m)
+	if m == 0 {
+		return
+	}
+	var sum float64
+	for _, d := range e.du {
+		sum += d
+	}
+	scale := float64(e.k) / float64(m)
+	fo.diskUsage += int64((scale - 1) * sum)
+	fo.size += int64((scale - 1) * float64(e.size))
+	fo.items += int64((scale - 1) * float64(e.items))
+	fo.duVar = scale * e.vars
+	if m > 1 && m < e.k {
+		mean := sum / float64(m)
+		var s2 float64
+		for _, d := range e.du {
+			s2 += (d - mean) * (d - mean)
+		}
+		s2 /= float64(m - 1)
+		fo.duVar += float64(e.k*e.k) * (1 - 1/scale) * s2 / float64(m)
+	}
+}
+
+/* Half width of the 95% confidence interval */
+func confidence(f *file) int64 {
+	return int64(1.96 * math.Sqrt(f.duVar))
+}
+
+func showestimate(sc *s_scan, fi []file, total *file) {
+	if sc.estimate <= 0 || sc.estDirs == 0 {
+		return
+	}
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("ESTIMATE"))
+	list := append([]file(nil), fi...)
+	sortFiles(sc, list)
+	for i, f := range list {
+		if i >= sc.maxShownLines {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%s\n", f.diskUsage, confidence(&f), fullPath(sc, f.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s| ±%10s| %s\n", i+1, fmtSz(sc, f.diskUsage),
+			fmtSz(sc, confidence(&f)), smartTruncate(f.name, sc.maxNameLen))
+	}
+	if sc.plain {
+		return
+	}
+	fmt.Fprintf(sc.out, tr("  =%13s| ±%10s| at 95%% confidence\n"), fmtSz(sc, total.diskUsage),
+		fmtSz(sc, confidence(total)))
+	fmt.Fprintf(sc.out, tr("  %d of %d subdirectories scanned (%.1f%%), the other lists only cover them.\n"),
+		sc.estScanned, sc.estDirs, float64(sc.estScanned)*100/float64(sc.estDirs))
+}
@@ -0,0 +1,261 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Excluded items are neither scanned nor counted in the totals. */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+)
+
+/* Snapshots of snapper (.snapshots) and timeshift (btrfs and rsync modes)
+ * would make the usage appear many times larger than reality. */
+func isSnapshotDir(path string, fi os.DirEntry) bool {
+	if !fi.IsDir() {
+		return false
+	}
+	switch fi.Name() {
+	case ".snapshots", "timeshift-btrfs":
+		return true
+	case "snapshots":
+		return filepath.Base(filepath.Dir(path)) == "timeshift"
+	}
+	return false
+}
+
+/* Kernel pseudo-filesystems skipped by the --system preset */
+var systemDirs = []string{"/proc", "/sys", "/dev", "/run"}
+
+func isSystemDir(sc *s_scan, path string) bool {
+	p := fullPath(sc, path)
+	for _, d := range systemDirs {
+		if p == d {
+			return true
+		}
+	}
+	return false
+}
+
+/* Directories can opt out of scans with an extended attribute, either
+ * user.tdu.exclude=1 or the XDG robots tags honored by backup tools
+ * (user.xdg.robots.index=false, user.xdg.robots.backup=false). */
+func hasExcludeTag(sc *s_scan, path string, fi os.DirEntry) bool {
+	if !fi.IsDir() {
+		return false
+	}
+	if v, ok := sc.fs.getXattr(path, "user.tdu.exclude"); ok {
+		return v == "1" || strings.EqualFold(v, "true")
+	}
+	for _, n := range []string{"user.xdg.robots.index", "user.xdg.robots.backup"} {
+		if v, ok := sc.fs.getXattr(path, n); ok && strings.EqualFold(v, "false") {
+			return true
+		}
+	}
+	return false
+}
+
+/* Cache directories are tagged with a CACHEDIR.TAG file starting with a
+ * fixed signature (https://bford.info/cachedir/), honored by tar and ncdu. */
+const cachedir_TAG = "CACHEDIR.TAG"
+
+var cachedirSignature = []byte("Signature: 8a477f597d28d172789f06886806bc55")
+
+func hasCacheTag(sc *s_scan, path string, dir scan_dir) bool {
+	f, err := sc.fs.open(filepath.Join(path, cachedir_TAG), dir)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	b := make([]byte, len(cachedirSignature))
+	if _, err := io.ReadFull(f, b); err != nil {
+		return false
+	}
+	return bytes.Equal(b, cachedirSignature)
+}
+
+/* Tagged directory found among the entries of a directory, with
+ * --exclude-caches: it is scanned, and its usage is shown apart. */
+func isCacheDir(sc *s_scan, path string, dir scan_dir, fs []os.DirEntry) bool {
+	if !sc.excludeCaches || sc.cacheDepth > 0 {
+		return false // nested caches are counted in the outer one
+	}
+	for _, e := range fs {
+		if e.Name() == cachedir_TAG && e.Type().IsRegular() {
+			return hasCacheTag(sc, path, dir)
+		}
+	}
+	return false
+}
+
+/* Shell patterns given with --exclude (repeatable) */
+type patterns []string
+
+func (p *patterns) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *patterns) Set(v string) error {
+	if _, err := pathpkg.Match(v, ""); err != nil {
+		return fmt.Errorf("%s: %v", v, err)
+	}
+	*p = append(*p, v)
+	return nil
+}
+
+/* Files of patterns given with --exclude-from: one pattern per line,
+ * blank lines and lines starting with # are ignored. */
+type patternFile struct {
+	p *patterns
+}
+
+func (f patternFile) String() string {
+	return ""
+}
+
+func (f patternFile) Set(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for _, l := range strings.Split(string(b), "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		if err := f.p.Set(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Patterns without a slash match the name of the item, the other ones
+ * match its path relative to the scanned directory. */
+func (p patterns) match(rel, name string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, pat := range p {
+		var ok bool
+		if strings.Contains(pat, "/") {
+			ok, _ = pathpkg.Match(strings.TrimPrefix(pat, "/"), rel)
+		} else {
+			ok, _ = pathpkg.Match(pat, name)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+/* Returns true if the item must be skipped */
+func excluded(sc *s_scan, path string, fi os.DirEntry) bool {
+	if sc.excludes.match(path, fi.Name()) {
+		sc.nExcluded++
+		return true
+	}
+	if sc.system && isSystemDir(sc, path) {
+		sc.nExcluded++
+		return true
+	}
+	if !sc.snapshots && isSnapshotDir(path, fi) {
+		sc.nExcluded++
+		sc.snapshotdirs = append(sc.snapshotdirs, path)
+		m := fmt.Sprintf(tr("  Not scanning snapshots at %s"), fullPath(sc, path))
+		push(sc, m)
+		return true
+	}
+	if len(sc.dupMounts) > 0 && fi.IsDir() {
+		if why, ok := sc.dupMounts[scanPath(sc, path)]; ok {
+			sc.nExcluded++
+			sc.dupdirs = append(sc.dupdirs, listPath(sc, path)+" ("+why+")")
+			push(sc, fmt.Sprintf(tr("  Not scanning %s (%s)"), fullPath(sc, path), why))
+			return true
+		}
+	}
+	if sc.skipCaches && fi.IsDir() && hasCacheTag(sc, path, nil) {
+		sc.nExcluded++
+		push(sc, fmt.Sprintf(tr("  Not scanning %s (cache directory)"), fullPath(sc, path)))
+		return true
+	}
+	if sc.prune != nil && sc.prune.skip && isPruned(sc, fi) {
+		sc.nExcluded++
+		return true
+	}
+	if sc.git != nil && sc.git.skip && gitIgnored(sc, path, fi) {
+		sc.nExcluded++
+		return true
+	}
+	if sc.xattrExclude && hasExcludeTag(sc, path, fi) {
+		sc.nExcluded++
+		push(sc, fmt.Sprintf(tr("  Not scanning %s (excluded by xattr)"), fullPath(sc, path)))
+		return true
+	}
+	return false
+}
+
+func showsnapshots(sc *s_scan) {
+	if len(sc.snapshotdirs) == 0 {
+		return
+	}
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("SKIPPED SNAPSHOTS"))
+	for i, d := range sc.snapshotdirs {
+		i++
+		printListItem(sc, i, listPath(sc, d))
+	}
+	fmt.Fprintln(sc.out, tr("  Use --snapshots to include them."))
+}
+
+func showdupmounts(sc *s_scan) {
+	if len(sc.dupdirs) == 0 {
+		return
+	}
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("SKIPPED DUPLICATE MOUNTS"))
+	for i, d := range sc.dupdirs {
+		printListItem(sc, i+1, d)
+	}
+}
+
+func showcachedirs(sc *s_scan) {
+	if len(sc.cachedirs) == 0 {
+		return
+	}
+	sortFiles(sc, sc.cachedirs)
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("CACHE DIRECTORIES"))
+	var total int64
+	for i, f := range sc.cachedirs {
+		total += f.diskUsage
+		if i >= sc.maxShownLines {
+			continue
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\n", f.diskUsage, fullPath(sc, f.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s| %s\n", i+1, fmtSz(sc, f.diskUsage),
+			smartTruncate(f.path, sc.maxNameLen+18))
+	}
+	if sc.plain {
+		return
+	}
+	fmt.Fprintf(sc.out, tr("  =%13s| in %d directories tagged with CACHEDIR.TAG\n"),
+		fmtSz(sc, total), len(sc.cachedirs))
+	fmt.Fprintln(sc.out, tr("  Use --exclude-caches-all to skip them."))
+}
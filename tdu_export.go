@@ -11,9 +11,13 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
+	"unicode/utf8"
 )
 
 const (
@@ -25,10 +29,14 @@ const (
 )
 
 func initExport(sc *s_scan) {
+	if sc.exportPath == "-" {
+		sc.exportFile = os.Stdout
+		return
+	}
 	mode := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
 	f, err := os.OpenFile(sc.exportPath, mode, 0666)
 	if err != nil {
-		fmt.Printf("\n  [ERROR] Cannot open export file: %v\n\n", err)
+		fmt.Fprintf(sc.out, tr("\n  [ERROR] Cannot open export file: %v\n\n"), err)
 		os.Exit(1)
 	}
 	sc.exportFile = f
@@ -39,25 +47,34 @@ func ncduOpe(operation int, sc *s_scan) {
 		return
 	}
 	var s string
+	if sc.exportSep && operation != ncdu_CLOSEDIR && operation != ncdu_NEXT {
+		s = ",\n"
+	}
+	sc.exportSep = false
 	switch operation {
 	case ncdu_INIT:
 		initExport(sc)
 		s = "[1,1,{\"progname\":\"tdu\","
 		s += fmt.Sprintf("\"progver\":\"%s\",", prg_VERSION)
-		s += fmt.Sprintf("\"timestamp\":%d},\n", time.Now().Unix())
+		ts := time.Now().Unix()
+		if sc.deterministic {
+			ts = 0
+		}
+		s += fmt.Sprintf("\"timestamp\":%d},\n", ts)
 	case ncdu_OPENDIR:
-		s = "["
+		s += "["
 	case ncdu_CLOSEDIR:
 		s = "]"
-	case ncdu_NEXT:
-		s = ",\n"
+	case ncdu_NEXT: // separator is written before the next item, if any
+		sc.exportSep = true
+		return
 	case ncdu_END:
 		s = "]\n"
 	default:
 		panic("Unknown operation")
 	}
 	sc.exportFile.WriteString(s)
-	if operation == ncdu_END {
+	if operation == ncdu_END && sc.exportFile != os.Stdout {
 		sc.exportFile.Close()
 	}
 }
@@ -75,52 +92,169 @@ func ncduDiskUsage(sc *s_scan, f *file) (int64, bool) {
 	return f.diskUsage, false
 }
 
-func cleanName(s string) string {
-	rs := []rune(s)
-	rd := make([]rune, 0, len(s))
-	for i := 0; i < len(rs); i++ {
-		if rs[i] <= 31 || rs[i] == 34 || rs[i] == 127 {
-			u := []rune(fmt.Sprintf("\\u00%02X", rs[i]))
-			rd = append(rd, u...)
-		} else {
-			rd = append(rd, rs[i])
+/* JSON of v, without the newline of json.Encoder nor the escaping of
+ * <, > and & that json.Marshal does for HTML pages */
+func marshalJSON(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+/* Length of the valid UTF-8 at the start of s */
+func validPrefix(s string) int {
+	i := 0
+	for i < len(s) {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 {
+			break
+		}
+		i += n
+	}
+	return i
+}
+
+/* A name of the Ncdu export. Its bytes that are not valid UTF-8 are
+ * written as they are, like ncdu does, the rest being escaped by
+ * encoding/json (which alone would replace them by U+FFFD). Escapes are
+ * ASCII, so they never touch an invalid byte and the name reads back
+ * unchanged, see UnmarshalJSON. */
+type ncdu_name string
+
+func (n ncdu_name) MarshalJSON() ([]byte, error) {
+	b := []byte{'"'}
+	for s := string(n); len(s) > 0; {
+		i := validPrefix(s)
+		if i == 0 {
+			b = append(b, s[0])
+			s = s[1:]
+			continue
+		}
+		q, err := marshalJSON(s[:i])
+		if err != nil {
+			return nil, err
 		}
+		b = append(b, q[1:len(q)-1]...)
+		s = s[i:]
 	}
-	return string(rd)
+	return append(b, '"'), nil
+}
+
+func (n *ncdu_name) UnmarshalJSON(b []byte) error {
+	if utf8.Valid(b) {
+		return json.Unmarshal(b, (*string)(n))
+	}
+	if len(b) < 2 || b[0] != '"' || b[len(b)-1] != '"' {
+		return fmt.Errorf("invalid name: %q", b)
+	}
+	var name []byte
+	for s := string(b[1 : len(b)-1]); len(s) > 0; {
+		i := validPrefix(s)
+		if i == 0 {
+			name = append(name, s[0])
+			s = s[1:]
+			continue
+		}
+		var v string
+		if err := json.Unmarshal([]byte("\""+s[:i]+"\""), &v); err != nil {
+			return err
+		}
+		name = append(name, v...)
+		s = s[i:]
+	}
+	*n = ncdu_name(name)
+	return nil
+}
+
+type ncdu_entry struct { // One item of the Ncdu export
+	Name      ncdu_name `json:"name"`
+	Size      int64     `json:"asize,omitempty"`
+	DiskUsage int64     `json:"dsize,omitempty"`
+	Device    *uint64   `json:"dev,omitempty"`
+	Inode     uint64    `json:"ino"`
+	Hardlink  bool      `json:"hlnkc,omitempty"`
+	NotReg    bool      `json:"notreg,omitempty"`
+	ReadError bool      `json:"read_error,omitempty"`
+	Excluded  string    `json:"excluded,omitempty"`
 }
 
 func ncduAdd(sc *s_scan, f *file) {
 	if !sc.export {
 		return
 	}
-	name := cleanName(f.name)
-	if f.depth == 1 {
-		name, _ = os.Getwd()
+	if sc.exportSep {
+		sc.exportFile.WriteString(",\n")
+		sc.exportSep = false
 	}
-	s := fmt.Sprintf("{\"name\":\"%s\"", name)
-	if f.size > 0 && !f.isOtherFs {
-		s += fmt.Sprintf(",\"asize\":%d", f.size)
+	e := ncdu_entry{Name: ncdu_name(f.name), Inode: f.inode, ReadError: f.readError,
+		NotReg: !f.isDir && !f.isRegular}
+	if f.depth == 1 {
+		wd, _ := os.Getwd()
+		e.Name = ncdu_name(wd)
 	}
 	du, hl := ncduDiskUsage(sc, f)
-	if du > 0 && !f.isOtherFs {
-		s += fmt.Sprintf(",\"dsize\":%d", du)
+	if !f.isOtherFs {
+		e.Size, e.DiskUsage = f.size, du
 	}
-	if f.depth == 1 || f.isOtherFs {
-		s += fmt.Sprintf(",\"dev\":%d", f.deviceId)
+	if f.depth == 1 || f.isOtherFs || (f.isTagged && f.isDir) {
+		e.Device = &f.deviceId
 	}
-	s += fmt.Sprintf(",\"ino\":%d", f.inode)
-	if hl {
-		s += ",\"hlnkc\":true"
+	e.Hardlink = hl
+	if f.isOtherFs {
+		e.Excluded = "othfs"
 	}
-	if !f.isDir && !f.isRegular {
-		s += ",\"notreg\":true"
+	b, err := marshalJSON(e)
+	if err != nil {
+		return
 	}
-	if f.readError {
-		s += ",\"read_error\":true"
+	sc.exportFile.Write(b)
+}
+
+type jsonl_entry struct { // One line of the --jsonl stream
+	Path      string `json:"path"`
+	Type      string `json:"type"`
+	Size      int64  `json:"asize"`
+	DiskUsage int64  `json:"dsize"`
+	Items     int64  `json:"items,omitempty"`
+	Depth     int64  `json:"depth"`
+	Inode     uint64 `json:"ino"`
+	Device    uint64 `json:"dev"`
+	Links     uint64 `json:"nlink,omitempty"`
+	ReadError bool   `json:"read_error,omitempty"`
+	OtherFs   bool   `json:"other_fs,omitempty"`
+}
+
+func fileType(f *file) string {
+	switch {
+	case f.isDir:
+		return "dir"
+	case f.isSymlink:
+		return "symlink"
+	case f.isRegular:
+		return "file"
 	}
-	if f.isOtherFs {
-		s += ",\"excluded\":\"othfs\""
+	return "special"
+}
+
+/* Directories are streamed when their content is fully scanned,
+ * with their total size and number of items. */
+func jsonlAdd(sc *s_scan, f *file, total *file) {
+	if !sc.jsonl {
+		return
 	}
-	s += "}"
-	sc.exportFile.WriteString(s)
+	e := jsonl_entry{Path: filepath.Clean(f.fullpath), Type: fileType(f), Size: f.size,
+		DiskUsage: f.diskUsage, Depth: f.depth, Inode: f.inode,
+		Device: f.deviceId, Links: f.nLinks, ReadError: f.readError,
+		OtherFs: f.isOtherFs || f.isTagged}
+	if total != nil {
+		e.Size, e.DiskUsage, e.Items = total.size, total.diskUsage, total.items
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	os.Stdout.Write(append(b, '\n'))
 }
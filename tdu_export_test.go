@@ -0,0 +1,110 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var exportNames = []struct {
+	name string
+	json string // as written in the export
+}{
+	{"plain", `"plain"`},
+	{"a<b>&c", `"a<b>&c"`},
+	{`quote" back\slash`, `"quote\" back\\slash"`},
+	{"tab\tnew\nline", `"tab\tnew\nline"`},
+	{"ctl\x01", `"ctl\u0001"`},
+	{"é ü 日本", `"é ü 日本"`},
+	{"\u2028", `"\u2028"`}, // escaped by encoding/json for JavaScript
+	{"\ufffd", "\"\ufffd\""},
+	{"latin1 caf\xe9", "\"latin1 caf\xe9\""},
+	{"\xff\xfe", "\"\xff\xfe\""},
+	{"\xc3", "\"\xc3\""}, // truncated sequence
+	{"\xe9\"\\\n", "\"\xe9\\\"\\\\\\n\""},
+}
+
+func TestNcduNameRoundTrip(t *testing.T) {
+	for _, c := range exportNames {
+		b, err := marshalJSON(ncdu_entry{Name: ncdu_name(c.name)})
+		if err != nil {
+			t.Errorf("%q: %v", c.name, err)
+			continue
+		}
+		if !strings.HasPrefix(string(b), `{"name":`+c.json+`,`) {
+			t.Errorf("%q: written as %s, want %s", c.name, b, c.json)
+		}
+		var n node
+		if err := json.Unmarshal(b, &n); err != nil {
+			t.Errorf("%q: %v", c.name, err)
+			continue
+		}
+		if n.Name != c.name {
+			t.Errorf("%q: read back as %q", c.name, n.Name)
+		}
+	}
+}
+
+func TestMarshalJSONNoHTMLEscape(t *testing.T) {
+	b, err := marshalJSON(ncdu_entry{Name: "<&>", Excluded: "a<b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"<&>","ino":0,"excluded":"a<b"}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+/* Writes an export of a directory holding the test names, then reads it
+ * back with --import */
+func TestNcduExportRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	sc := newScanStruct(time.Now(), nil)
+	sc.con, sc.out, sc.info = io.Discard, io.Discard, io.Discard
+	sc.export, sc.deterministic = true, true
+	sc.exportPath = filepath.Join(dir, "export.json")
+	ncduInit(sc)
+	ncduOpenDir(sc)
+	ncduAdd(sc, &file{name: ".", depth: 1, isDir: true, deviceId: 7})
+	for i, c := range exportNames {
+		ncduNext(sc)
+		ncduAdd(sc, &file{name: c.name, depth: 2, isRegular: true,
+			size: int64(i), diskUsage: 4096, inode: uint64(i + 1)})
+	}
+	ncduCloseDir(sc)
+	ncduEnd(sc)
+
+	root, err := readNcdu(sc.exportPath)
+	if err != nil {
+		b, _ := os.ReadFile(sc.exportPath)
+		t.Fatalf("%v\n%s", err, b)
+	}
+	if wd, _ := os.Getwd(); root.Name != wd || root.Device != 7 || !root.IsDir {
+		t.Errorf("root: %+v", root)
+	}
+	if len(root.Children) != len(exportNames) {
+		t.Fatalf("%d items read, want %d", len(root.Children), len(exportNames))
+	}
+	for i, c := range exportNames {
+		n := root.Children[i]
+		if n.Name != c.name || n.Size != int64(i) || n.DiskUsage != 4096 ||
+			n.Inode != uint64(i+1) || n.Device != 7 {
+			t.Errorf("item %d: %+v, want name %q", i, n, c.name)
+		}
+	}
+}
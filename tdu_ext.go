@@ -0,0 +1,143 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Disk usage per file extension (--by-ext), and per category of files
+ * found from their extension (--categories) */
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type ext_usage struct {
+	ext       string
+	diskUsage int64
+	size      int64
+	count     int64
+}
+
+func countExt(sc *s_scan, f *file) {
+	if sc.exts == nil || !f.isRegular {
+		return
+	}
+	e := strings.ToLower(filepath.Ext(f.name))
+	u, ok := sc.exts[e]
+	if !ok {
+		u = &ext_usage{ext: e}
+		sc.exts[e] = u
+	}
+	u.diskUsage += f.diskUsage
+	u.size += f.size
+	u.count++
+}
+
+func showexts(sc *s_scan, total *file) {
+	t := shownSize(sc, total.diskUsage, total.size)
+	if len(sc.exts) == 0 || t == 0 {
+		return
+	}
+	var exts []*ext_usage
+	for _, u := range sc.exts {
+		exts = append(exts, u)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		return shownSize(sc, exts[i].diskUsage, exts[i].size) >
+			shownSize(sc, exts[j].diskUsage, exts[j].size)
+	})
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("USAGE BY EXTENSION"))
+	for i, u := range exts {
+		if i >= sc.maxExts {
+			break
+		}
+		du := shownSize(sc, u.diskUsage, u.size)
+		name := u.ext
+		if name == "" {
+			name = tr("(none)")
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%s\n", du, u.count, name)
+			continue
+		}
+		p := float64(du*100.0) / float64(t)
+		fmt.Fprintf(sc.out, "%3d. %-10s|%12s|%6.2f%%|%9d %s\n", i+1, name,
+			fmtSz(sc, du), p, u.count, tr("files"))
+	}
+}
+
+/* Categories of the usual extensions, other files being counted apart */
+var extCategories = map[string][]string{
+	"videos":    {".mp4", ".mkv", ".avi", ".mov", ".wmv", ".webm", ".m4v", ".mpg", ".mpeg", ".flv", ".ts"},
+	"images":    {".jpg", ".jpeg", ".png", ".gif", ".bmp", ".tif", ".tiff", ".webp", ".heic", ".raw", ".cr2", ".nef", ".svg", ".psd"},
+	"audio":     {".mp3", ".flac", ".wav", ".ogg", ".m4a", ".aac", ".opus", ".wma"},
+	"archives":  {".zip", ".tar", ".gz", ".tgz", ".bz2", ".xz", ".zst", ".7z", ".rar", ".deb", ".rpm", ".jar"},
+	"vm images": {".iso", ".img", ".qcow2", ".vdi", ".vmdk", ".vhd", ".vhdx", ".ova"},
+	"documents": {".pdf", ".doc", ".docx", ".odt", ".xls", ".xlsx", ".ods", ".ppt", ".pptx", ".odp", ".txt", ".md", ".epub", ".rtf"},
+	"code":      {".c", ".h", ".cpp", ".go", ".py", ".js", ".java", ".rs", ".rb", ".php", ".sh", ".html", ".css", ".json", ".xml", ".yml", ".yaml"},
+}
+
+var categoryOf = func() map[string]string {
+	m := make(map[string]string)
+	for c, exts := range extCategories {
+		for _, e := range exts {
+			m[e] = c
+		}
+	}
+	return m
+}()
+
+func countCategory(sc *s_scan, f *file) {
+	if sc.categories == nil || !f.isRegular {
+		return
+	}
+	c, ok := categoryOf[strings.ToLower(filepath.Ext(f.name))]
+	if !ok {
+		c = "other"
+	}
+	u, ok := sc.categories[c]
+	if !ok {
+		u = &ext_usage{ext: c}
+		sc.categories[c] = u
+	}
+	u.diskUsage += f.diskUsage
+	u.size += f.size
+	u.count++
+}
+
+func showcategories(sc *s_scan, total *file) {
+	t := shownSize(sc, total.diskUsage, total.size)
+	if len(sc.categories) == 0 || t == 0 {
+		return
+	}
+	var list []*ext_usage
+	for _, u := range sc.categories {
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return shownSize(sc, list[i].diskUsage, list[i].size) >
+			shownSize(sc, list[j].diskUsage, list[j].size)
+	})
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("USAGE BY CATEGORY"))
+	for i, u := range list {
+		du := shownSize(sc, u.diskUsage, u.size)
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%s\n", du, u.count, u.ext)
+			continue
+		}
+		p := float64(du*100.0) / float64(t)
+		fmt.Fprintf(sc.out, "%3d. %-10s|%12s|%6.2f%%|%9d %s\n", i+1, tr(u.ext),
+			fmtSz(sc, du), p, u.count, tr("files"))
+	}
+}
@@ -0,0 +1,97 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* The system calls of the scan go through sc.fs, so that scan(), the
+ * hardlink and boundary detection and the exports can be run against an
+ * in-memory tree with chosen inode and device numbers. A fake may return
+ * no directory handle: its entries are then stat'ed by path with lstat,
+ * and the Sys() of its FileInfo gives the inode, device and blocks read
+ * by sysStat().
+ *
+ * Still read from the system: the partition of the working directory and
+ * the mount tables (partInfo, pseudo filesystems, btrfs subvolumes, which
+ * a fake tree has none of), the chdir to the scanned directory done by
+ * main(), the sysStat() of Windows, and the reports that read files after
+ * the scan (--manifest, --vm-images, --docker, --logs, --reclaim). */
+
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type scan_dir interface { // handle of a directory, an *os.Root for os_fs
+	Close() error
+}
+
+type scan_fs interface {
+	// Stat of path, without following symbolic links
+	lstat(path string) (os.FileInfo, error)
+	// Handle of a directory, relative to the one of its parent when not
+	// nil. A nil handle is not an error, see readDir.
+	openDir(path string, parent scan_dir) (scan_dir, error)
+	// Entries of a directory, through its handle when not nil
+	readDir(path string, dir scan_dir) ([]os.DirEntry, error)
+	// A file read by the scan (CACHEDIR.TAG, .gitignore), relative to
+	// the handle of its directory when not nil
+	open(path string, dir scan_dir) (io.ReadCloser, error)
+	// Size of the extended attributes of path (--xattrs)
+	xattrSize(path string) int64
+	// Value of an extended attribute of path, see hasExcludeTag
+	getXattr(path, name string) (string, bool)
+	// Bytes of the extents of a file, and of those shared (--shared)
+	fileExtents(path string) (int64, int64, bool)
+}
+
+type os_fs struct{} // the real filesystem
+
+func (os_fs) lstat(path string) (os.FileInfo, error) {
+	return os.Lstat(longPath(path))
+}
+
+func (os_fs) openDir(path string, parent scan_dir) (scan_dir, error) {
+	var r *os.Root
+	var err error
+	if p, ok := parent.(*os.Root); ok {
+		r, err = p.OpenRoot(filepath.Base(path))
+	} else {
+		r, err = os.OpenRoot(path)
+	}
+	if err != nil {
+		return nil, err // not a nil *os.Root in a non-nil scan_dir
+	}
+	return r, nil
+}
+
+func (os_fs) readDir(path string, dir scan_dir) ([]os.DirEntry, error) {
+	r, _ := dir.(*os.Root)
+	return readDir(path, r)
+}
+
+func (os_fs) open(path string, dir scan_dir) (io.ReadCloser, error) {
+	if r, ok := dir.(*os.Root); ok {
+		return r.Open(filepath.Base(path))
+	}
+	return os.Open(longPath(path))
+}
+
+func (os_fs) xattrSize(path string) int64 {
+	return xattrSize(path)
+}
+
+func (os_fs) getXattr(path, name string) (string, bool) {
+	return getXattr(path, name)
+}
+
+func (os_fs) fileExtents(path string) (int64, int64, bool) {
+	return fileExtents(path)
+}
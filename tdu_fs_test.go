@@ -0,0 +1,304 @@
+// +build linux freebsd darwin openbsd netbsd
+
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* In-memory tree for the tests of the scan, see scan_fs. The Sys() of its
+ * items is a syscall.Stat_t, as sysStat() expects on these systems. */
+
+package main
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type mem_node struct {
+	mode   os.FileMode
+	size   int64
+	blocks int64 // of 512 bytes
+	ino    uint64
+	dev    uint64
+	nlink  uint64
+	data   string // content of a file read by the scan
+	xattrs map[string]string
+}
+
+type mem_fs map[string]*mem_node // by path, "." being the scanned directory
+
+var mem_TIME = time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func memDir(dev, ino uint64) *mem_node {
+	return &mem_node{mode: os.ModeDir | 0755, size: 4096, blocks: 8, ino: ino,
+		dev: dev, nlink: 2}
+}
+
+func memFile(dev, ino uint64, size int64) *mem_node {
+	return &mem_node{mode: 0644, size: size, blocks: (size + 4095) / 4096 * 8,
+		ino: ino, dev: dev, nlink: 1}
+}
+
+func memLink(n *mem_node, nlink uint64) *mem_node {
+	n.nlink = nlink
+	return n
+}
+
+type mem_info struct {
+	name string
+	n    *mem_node
+}
+
+func (i mem_info) Name() string       { return i.name }
+func (i mem_info) Size() int64        { return i.n.size }
+func (i mem_info) Mode() os.FileMode  { return i.n.mode }
+func (i mem_info) ModTime() time.Time { return mem_TIME }
+func (i mem_info) IsDir() bool        { return i.n.mode.IsDir() }
+
+/* Fields of Stat_t have other types on some systems and architectures */
+func setStat(p interface{}, v int64) {
+	f := reflect.ValueOf(p).Elem()
+	if f.CanInt() {
+		f.SetInt(v)
+	} else {
+		f.SetUint(uint64(v))
+	}
+}
+
+func (i mem_info) Sys() interface{} {
+	var st syscall.Stat_t
+	setStat(&st.Dev, int64(i.n.dev))
+	setStat(&st.Ino, int64(i.n.ino))
+	setStat(&st.Nlink, int64(i.n.nlink))
+	setStat(&st.Size, i.n.size)
+	setStat(&st.Blocks, i.n.blocks)
+	setStat(&st.Blksize, 4096)
+	return &st
+}
+
+func (m mem_fs) lstat(path string) (os.FileInfo, error) {
+	n, ok := m[path]
+	if !ok {
+		return nil, &os.PathError{Op: "lstat", Path: path, Err: os.ErrNotExist}
+	}
+	return mem_info{filepath.Base(path), n}, nil
+}
+
+func (m mem_fs) openDir(path string, parent scan_dir) (scan_dir, error) {
+	return nil, nil // entries are stat'ed by path
+}
+
+func (m mem_fs) readDir(path string, dir scan_dir) ([]os.DirEntry, error) {
+	if n, ok := m[path]; !ok || !n.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: path, Err: syscall.ENOTDIR}
+	}
+	var names []string
+	for p := range m {
+		if p != "." && filepath.Dir(p) == path {
+			names = append(names, filepath.Base(p))
+		}
+	}
+	sort.Strings(names)
+	var entries []os.DirEntry
+	for _, name := range names {
+		i, _ := m.lstat(filepath.Join(path, name))
+		entries = append(entries, fs.FileInfoToDirEntry(i))
+	}
+	return entries, nil
+}
+
+func (m mem_fs) open(path string, dir scan_dir) (io.ReadCloser, error) {
+	n, ok := m[path]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+	return io.NopCloser(strings.NewReader(n.data)), nil
+}
+
+func (m mem_fs) xattrSize(path string) int64 {
+	var size int64
+	for k, v := range m[path].xattrs {
+		size += int64(len(k) + len(v))
+	}
+	return size
+}
+
+func (m mem_fs) getXattr(path, name string) (string, bool) {
+	v, ok := m[path].xattrs[name]
+	return v, ok
+}
+
+func (m mem_fs) fileExtents(path string) (int64, int64, bool) {
+	return 512 * m[path].blocks, 0, true
+}
+
+/* Scan of a fake tree with the settings of a default run, changed by set */
+func memScan(t *testing.T, m mem_fs, set func(sc *s_scan)) (*s_scan, []file, *file) {
+	t.Helper()
+	sc := newScanStruct(mem_TIME, nil)
+	sc.con, sc.out, sc.info = io.Discard, io.Discard, io.Discard
+	sc.fs = m
+	sc.root = "/mem"
+	sc.maxBigFiles, sc.maxEmptyDirs = dft_MAXBIGFILES, dft_MAXEMPTYDIRS
+	sc.maxDenied, sc.maxErrors = dft_MAXDENIEDDIRS, dft_MAXSTATERROR
+	sc.maxXattrs, sc.maxDepth = dft_MAXXATTRS, 1
+	if set != nil {
+		set(sc)
+	}
+	gitInit(sc)
+	var fi []file
+	total, err := scan(sc, &fi, ".", 1, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sc, fi, total
+}
+
+var cacheTag = "Signature: 8a477f597d28d172789f06886806bc55\n"
+
+func TestScanMemTree(t *testing.T) {
+	tests := []struct {
+		name      string
+		tree      mem_fs
+		set       func(sc *s_scan)
+		du, size  int64
+		items     int64
+		hardlinks int64
+		boundary  bool
+		otherfs   []string
+		top       []string // depth 1 items
+	}{{
+		name: "files and directories",
+		tree: mem_fs{".": memDir(1, 1), "a": memDir(1, 2),
+			"a/big": memFile(1, 3, 10000), "b": memFile(1, 4, 100)},
+		du: 4096*3 + 12288, size: 4096*2 + 10000 + 100, items: 3,
+		top: []string{"a", "b"},
+	}, {
+		name: "hardlinks counted once",
+		tree: mem_fs{".": memDir(1, 1), "a": memDir(1, 2),
+			"a/f": memLink(memFile(1, 3, 8192), 2), "b": memLink(memFile(1, 3, 8192), 2)},
+		du: 4096*2 + 8192, size: 4096*2 + 8192*2, items: 3, hardlinks: 1,
+		top: []string{"a", "b"},
+	}, {
+		name: "same inode on two filesystems",
+		tree: mem_fs{".": memDir(1, 1), "a": memDir(1, 2),
+			"a/f": memLink(memFile(1, 9, 8192), 2),
+			"m":   memDir(2, 2), "m/f": memLink(memFile(2, 9, 8192), 2)},
+		set: func(sc *s_scan) { sc.tagOtherFs, sc.includePseudo = true, true },
+		du:  4096*3 + 8192*2, size: 4096*3 + 8192*2, items: 4, boundary: true,
+		otherfs: []string{"m"}, top: []string{"a", "m"},
+	}, {
+		name: "filesystem boundary not crossed",
+		tree: mem_fs{".": memDir(1, 1), "a": memFile(1, 2, 4096),
+			"mnt": memDir(2, 1), "mnt/f": memFile(2, 2, 1<<20)},
+		du: 4096 * 3, size: 4096 * 3, items: 2, boundary: true,
+		otherfs: []string{"mnt"}, top: []string{"a"},
+	}, {
+		name: "excludes",
+		tree: mem_fs{".": memDir(1, 1), "skip": memDir(1, 2),
+			"skip/f": memFile(1, 3, 4096), "tagged": memDir(1, 4),
+			"tagged/f": memFile(1, 5, 4096), "cache": memDir(1, 6),
+			"cache/CACHEDIR.TAG": &mem_node{mode: 0644, size: 44, blocks: 8,
+				ino: 7, dev: 1, nlink: 1, data: cacheTag},
+			"kept": memFile(1, 8, 100)},
+		set: func(sc *s_scan) {
+			sc.excludes = patterns{"skip"}
+			sc.xattrExclude, sc.skipCaches = true, true
+		},
+		du: 4096 * 2, size: 4096 + 100, items: 1, top: []string{"kept"},
+	}, {
+		name: "gitignored",
+		tree: mem_fs{".": memDir(1, 1), ".git": memDir(1, 2),
+			".gitignore": &mem_node{mode: 0644, size: 6, blocks: 8, ino: 3,
+				dev: 1, nlink: 1, data: "*.log\n"},
+			"a.log": memFile(1, 4, 4096), "a.c": memFile(1, 5, 4096)},
+		set: func(sc *s_scan) { sc.git = &git_state{skip: true} },
+		du:  4096 * 4, size: 4096*3 + 6, items: 3,
+		top: []string{".git", ".gitignore", "a.c"},
+	}}
+	tests[4].tree["tagged"].xattrs = map[string]string{"user.tdu.exclude": "1"}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sc, fi, total := memScan(t, tc.tree, tc.set)
+			if total.diskUsage != tc.du || total.size != tc.size || total.items != tc.items {
+				t.Errorf("du %d, size %d, items %d; want %d, %d, %d", total.diskUsage,
+					total.size, total.items, tc.du, tc.size, tc.items)
+			}
+			if sc.nHardlinks != tc.hardlinks {
+				t.Errorf("%d hardlinks, want %d", sc.nHardlinks, tc.hardlinks)
+			}
+			if sc.foundBoundary != tc.boundary {
+				t.Errorf("boundary found: %v, want %v", sc.foundBoundary, tc.boundary)
+			}
+			var other []string
+			for _, f := range sc.otherfs {
+				other = append(other, f.path)
+			}
+			if !reflect.DeepEqual(other, tc.otherfs) {
+				t.Errorf("other filesystems %q, want %q", other, tc.otherfs)
+			}
+			var top []string
+			for _, f := range fi {
+				top = append(top, f.path)
+			}
+			sort.Strings(top)
+			if !reflect.DeepEqual(top, tc.top) {
+				t.Errorf("depth 1 items %q, want %q", top, tc.top)
+			}
+		})
+	}
+}
+
+func TestXattrsMemTree(t *testing.T) {
+	tree := mem_fs{".": memDir(1, 1), "a": memFile(1, 2, 10), "b": memFile(1, 3, 10)}
+	tree["a"].xattrs = map[string]string{"user.x": "12345"}
+	sc, _, _ := memScan(t, tree, func(sc *s_scan) { sc.maxXattrs = 5 })
+	if sc.xattrTotal != 11 || len(sc.xattrs) != 1 || sc.xattrs[0].path != "a" {
+		t.Errorf("xattrs %d bytes: %+v", sc.xattrTotal, sc.xattrs)
+	}
+}
+
+/* The Ncdu export of a fake tree, read back, has its usage and links */
+func TestExportMemTree(t *testing.T) {
+	tree := mem_fs{".": memDir(1, 1), "a": memDir(1, 2),
+		"a/f": memLink(memFile(1, 3, 8192), 2), "b": memLink(memFile(1, 3, 8192), 2),
+		"mnt": memDir(2, 1), "mnt/g": memFile(2, 5, 4096)}
+	path := filepath.Join(t.TempDir(), "export.json")
+	sc, _, total := memScan(t, tree, func(sc *s_scan) {
+		sc.export, sc.exportPath, sc.deterministic = true, path, true
+		ncduInit(sc)
+	})
+	ncduEnd(sc)
+	root, err := readNcdu(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the mountpoint has no size in the export, being excluded as othfs
+	if du := root.total(make(ino_map), false); du != total.diskUsage-4096 {
+		t.Errorf("export total %d, scan total %d", du, total.diskUsage)
+	}
+	names := map[string]*node{}
+	for _, c := range root.Children {
+		names[c.Name] = c
+	}
+	if b := names["b"]; b == nil || !b.Hardlink || b.Inode != 3 || b.DiskUsage != 8192 {
+		t.Errorf("b: %+v", b)
+	}
+	if m := names["mnt"]; m == nil || m.Excluded != "othfs" || m.Device != 2 || len(m.Children) != 0 {
+		t.Errorf("mnt: %+v", m)
+	}
+}
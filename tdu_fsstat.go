@@ -0,0 +1,45 @@
+// +build darwin freebsd openbsd netbsd
+
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+package main
+
+const mnt_NOWAIT = 2 // getfsstat may return cached information
+
+func cstr(b []int8) string {
+	s := make([]byte, 0, len(b))
+	for _, c := range b {
+		if c == 0 {
+			break
+		}
+		s = append(s, byte(c))
+	}
+	return string(s)
+}
+
+/* Mounted filesystems reported by getfsstat (getvfsstat on NetBSD), as
+ * there is no /proc. The layout of the entries differs on each OS. */
+func statMounts[T any](getfsstat func([]T, int) (int, error), entry func(*T) mount_entry) []mount_entry {
+	n, err := getfsstat(nil, mnt_NOWAIT)
+	if err != nil || n <= 0 {
+		return nil
+	}
+	buf := make([]T, n)
+	n, err = getfsstat(buf, mnt_NOWAIT)
+	if err != nil {
+		return nil
+	}
+	mounts := make([]mount_entry, 0, n)
+	for i := range buf[:n] {
+		mounts = append(mounts, entry(&buf[i]))
+	}
+	return mounts
+}
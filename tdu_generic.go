@@ -1,6 +1,9 @@
 // +build !linux
 // +build !windows
 // +build !freebsd
+// +build !darwin
+// +build !openbsd
+// +build !netbsd
 
 /* Top Disk Usage.
  * Copyright (C) 2019 Joseph Paul <joseph.paul1@gmx.com>
@@ -18,6 +21,8 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"syscall"
 )
 
 func osInit() bool {
@@ -34,13 +39,73 @@ func getTtyWidth(sc *s_scan) int {
 
 func initTty(sc *sc_scan) {} // OS Specific
 
+func watchResize(sc *s_scan) {}
+
+const ( // Growth markers in the table
+	arrow_UP   = "+"
+	arrow_DOWN = "-"
+	bar_CHAR   = "#" // bar charts
+	bar_FULL   = "#" // bars of the main table
+	bar_EMPTY  = "."
+)
+
 func printAlert(sc *s_scan, msg string) {
-	fmt.Printf(msg)
+	fmt.Fprint(sc.out, msg)
+}
+
+func printShare(sc *s_scan, level int, msg string) {
+	fmt.Fprint(sc.out, msg)
 }
 
 func printProgress(sc *s_scan) {
 	n := sc.nErrors + sc.nItems
-	fmt.Printf("  [.... scanning... %6d  ....]\r", n)
+	fmt.Fprintf(sc.con, tr("  [.... scanning... ")+"%6d%s\r", n, progressInfo(sc))
+}
+
+func getPartition(sc *s_scan, dev uint64) string {
+	return fmt.Sprintf("[dev 0x%04X]", dev)
+}
+
+// Mountpoints are not enumerated on this OS
+func listMounts(sc *s_scan) []string {
+	return nil
+}
+
+func isLocked(errno syscall.Errno) bool {
+	return false
+}
+
+func isTransient(errno syscall.Errno) bool {
+	return false
+}
+
+// Extended attributes are not read on this OS
+func getXattr(path, name string) (string, bool) {
+	return "", false
+}
+
+func xattrSize(path string) int64 {
+	return 0
+}
+
+const dir_HANDLES = false // paths relative to the scanned directory
+
+func maxDirHandles() int64 {
+	return 0
+}
+
+// Paths have no length limit on this OS
+func longPath(path string) string {
+	return path
+}
+
+func fileOwner(f *file) (uint32, uint32, bool) {
+	return 0, 0, false
+}
+
+// Filesystem boundaries are not detected on this OS
+func fsStat(path string) (uint64, uint64, uint64, bool) {
+	return 0, 0, 0, false
 }
 
 // Disk usage is inaccurate because appropriate syscall is not yet implemented
@@ -53,3 +118,28 @@ func sysStat(sc *s_scan, f *file) error {
 	f.diskUsage = f.size
 	return nil
 }
+
+// Filesystem events are only watched on Linux, see usage()
+func newWatcher(sc *s_scan) scan_watcher { return nil }
+
+func watch(sc *s_scan, fi *[]file, t *file) {}
+
+// Directories are not opened in a Root on this OS, see dir_HANDLES
+func dirEntries(d *os.File) ([]os.DirEntry, error) {
+	return d.ReadDir(-1)
+}
+
+// Shared extents are only read on Linux, see --shared
+func fileExtents(path string) (int64, int64, bool) {
+	return 0, 0, false
+}
+
+// Mountpoints are not enumerated on this OS, see listMounts()
+func showMountList(sc *s_scan) {
+	fmt.Fprintln(sc.out, tr("  [ERROR] No local mountpoint found"))
+}
+
+// Bind mounts are only detected on Linux, from /proc/self/mountinfo
+func dupMounts(root string, crossFs bool) map[string]string {
+	return nil
+}
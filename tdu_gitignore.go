@@ -0,0 +1,259 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Inside git repositories, the items ignored by git (build artifacts,
+ * node_modules...) are shown apart with --respect-gitignore, or skipped
+ * with --exclude-gitignored. The .gitignore files are read while the tree
+ * is scanned, with .git/info/exclude, but not the global excludes file. */
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type git_rule struct {
+	base    string // directory of the .gitignore, absolute with slashes
+	re      *regexp.Regexp
+	anchor  bool // matches the path relative to base, else the name
+	neg     bool // !pattern
+	dirOnly bool // pattern/
+}
+
+type git_state struct {
+	skip     bool       // --exclude-gitignored
+	inRepo   bool       // the scanned directory is in a repository
+	rules    []git_rule // rules of the directory being scanned
+	ignoring int        // depth in an ignored directory
+	repos    int
+	total    int64
+	items    []file // largest ignored items
+}
+
+/* Regexp of a gitignore pattern. A '**' matches any number of
+ * directories, other wildcards do not match slashes. */
+func gitRegexp(pat string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pat); i++ {
+		c := pat[i]
+		switch {
+		case strings.HasPrefix(pat[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pat[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			j := strings.IndexByte(pat[i+1:], ']')
+			if j < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pat[i+1 : i+1+j]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += j + 1
+		case c == '\\' && i+1 < len(pat):
+			i++
+			b.WriteString(regexp.QuoteMeta(pat[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(pat[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+/* Rules of a .gitignore or info/exclude file, base being its directory */
+func readGitRules(sc *s_scan, name, base string) []git_rule {
+	f, err := sc.fs.open(name, nil)
+	if err != nil {
+		return nil
+	}
+	b, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil
+	}
+	var rules []git_rule
+	for _, l := range strings.Split(string(b), "\n") {
+		l = strings.TrimRight(l, "\r")
+		for strings.HasSuffix(l, " ") && !strings.HasSuffix(l, `\ `) {
+			l = l[:len(l)-1]
+		}
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		r := git_rule{base: base}
+		if strings.HasPrefix(l, "!") {
+			r.neg = true
+			l = l[1:]
+		}
+		if strings.HasSuffix(l, "/") {
+			r.dirOnly = true
+			l = strings.TrimRight(l, "/")
+		}
+		r.anchor = strings.Contains(l, "/")
+		l = strings.TrimPrefix(l, "/")
+		if l == "" {
+			continue
+		}
+		if r.re, err = gitRegexp(l); err != nil {
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+func hasEntry(fs []os.DirEntry, name string) bool {
+	for _, e := range fs {
+		if e.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+/* Repository holding the scanned directory, with the .gitignore files
+ * of the directories above it */
+func gitInit(sc *s_scan) {
+	g := sc.git
+	if g == nil {
+		return
+	}
+	g.inRepo, g.rules = false, nil
+	root := filepath.Clean(sc.root)
+	if _, err := sc.fs.lstat(".git"); err == nil {
+		return // see gitEnterDir
+	}
+	var dirs []string
+	for d := filepath.Dir(root); ; d = filepath.Dir(d) {
+		dirs = append(dirs, d)
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			g.inRepo = true
+			g.repos++
+			base := filepath.ToSlash(d)
+			g.rules = readGitRules(sc, filepath.Join(d, ".git", "info", "exclude"), base)
+			break
+		}
+		if filepath.Dir(d) == d {
+			return
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		g.rules = append(g.rules, readGitRules(sc, filepath.Join(dirs[i], ".gitignore"),
+			filepath.ToSlash(dirs[i]))...)
+	}
+}
+
+/* Rules of a directory that is a repository or has a .gitignore. The
+ * returned state is given back to gitLeaveDir once it is scanned. */
+func gitEnterDir(sc *s_scan, path string, fs []os.DirEntry) git_state {
+	g := sc.git
+	if g == nil {
+		return git_state{}
+	}
+	saved := *g
+	full := fullPath(sc, path)
+	base := filepath.ToSlash(full)
+	if hasEntry(fs, ".git") && g.ignoring == 0 { // own rules, even in a submodule
+		g.inRepo = true
+		g.repos++
+		g.rules = readGitRules(sc, filepath.Join(path, ".git", "info", "exclude"), base)
+	}
+	if g.inRepo && hasEntry(fs, ".gitignore") {
+		g.rules = append(g.rules[:len(g.rules):len(g.rules)],
+			readGitRules(sc, filepath.Join(path, ".gitignore"), base)...)
+	}
+	return saved
+}
+
+func gitLeaveDir(sc *s_scan, saved git_state) {
+	if g := sc.git; g != nil {
+		g.inRepo, g.rules = saved.inRepo, saved.rules
+	}
+}
+
+/* True if git ignores the item, the last matching rule deciding */
+func gitIgnored(sc *s_scan, path string, fi os.DirEntry) bool {
+	g := sc.git
+	if g == nil || !g.inRepo || g.ignoring > 0 || fi.Name() == ".git" {
+		return false
+	}
+	p := filepath.ToSlash(fullPath(sc, path))
+	ignored := false
+	for _, r := range g.rules {
+		if r.dirOnly && !fi.IsDir() {
+			continue
+		}
+		if !strings.HasPrefix(p, r.base+"/") && r.base != "/" {
+			continue
+		}
+		var s string
+		if r.anchor {
+			s = strings.TrimPrefix(strings.TrimPrefix(p, r.base), "/")
+		} else {
+			s = fi.Name()
+		}
+		if r.re.MatchString(s) {
+			ignored = !r.neg
+		}
+	}
+	return ignored
+}
+
+func gitAdd(sc *s_scan, f *file) {
+	g := sc.git
+	g.total += f.diskUsage
+	if len(g.items) > sc.maxShownLines*4 {
+		sortFiles(sc, g.items)
+		g.items = g.items[:sc.maxShownLines]
+	}
+	g.items = append(g.items, *f)
+}
+
+func showgitignored(sc *s_scan) {
+	g := sc.git
+	if g == nil || g.skip || len(g.items) == 0 {
+		return
+	}
+	sortFiles(sc, g.items)
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("IGNORED BY GIT"))
+	for i, f := range g.items {
+		if i >= sc.maxShownLines {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\n", f.diskUsage, fullPath(sc, f.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s| %s\n", i+1, fmtSz(sc, f.diskUsage),
+			smartTruncate(f.path, sc.maxNameLen+18))
+	}
+	if sc.plain {
+		return
+	}
+	fmt.Fprintf(sc.out, tr("  =%13s| ignored in %d git repositories\n"), fmtSz(sc, g.total), g.repos)
+	fmt.Fprintln(sc.out, tr("  Use --exclude-gitignored to skip them."))
+}
@@ -0,0 +1,112 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Usage of the git repositories found in the scanned directory
+ * (--git-repos): the history in .git, split into packs and loose objects,
+ * against the working tree. A .git larger than the checkout is flagged,
+ * as a history full of removed big files often is. */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type git_repo struct {
+	path  string
+	total int64 // the repository with its .git
+	git   int64
+	objs  int64 // .git/objects
+	packs int64 // .git/objects/pack
+}
+
+func repoOf(m map[string]*git_repo, key string) *git_repo {
+	if key == "" {
+		key = "."
+	}
+	r, ok := m[key]
+	if !ok {
+		r = &git_repo{path: key}
+		m[key] = r
+	}
+	return r
+}
+
+/* Usage of .git, .git/objects and .git/objects/pack, found while the
+ * content of path is scanned */
+func gitRepoChild(sc *s_scan, path, name string, cf *file) {
+	if sc.repos == nil || !cf.isDir {
+		return
+	}
+	sep := sc.pathSeparator
+	dir := sep + path // so that "." and ".git" have a leading separator
+	repo := func(sub string) *git_repo {
+		return repoOf(sc.repos, strings.TrimPrefix(strings.TrimSuffix(dir, sub), sep))
+	}
+	switch {
+	case name == ".git":
+		repoOf(sc.repos, path).git = cf.diskUsage
+	case name == "objects" && strings.HasSuffix(dir, sep+".git"):
+		repo(sep + ".git").objs = cf.diskUsage
+	case name == "pack" && strings.HasSuffix(dir, sep+".git"+sep+"objects"):
+		repo(sep + ".git" + sep + "objects").packs = cf.diskUsage
+	}
+}
+
+func gitRepoDone(sc *s_scan, path string, fo *file) {
+	if r, ok := sc.repos[path]; ok {
+		r.total = fo.diskUsage
+	}
+}
+
+func showgitrepos(sc *s_scan) {
+	var list []*git_repo
+	for _, r := range sc.repos {
+		if r.git > 0 {
+			list = append(list, r)
+		}
+	}
+	if len(list) == 0 {
+		return
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].git > list[j].git })
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("GIT REPOSITORIES"))
+	if !sc.plain {
+		fmt.Fprint(sc.out, tr("            .git|       Packs|       Loose|Working tree|\n"))
+	}
+	var n int
+	for i, r := range list {
+		work := r.total - r.git
+		if r.git > work {
+			n++
+		}
+		if i >= sc.maxShownLines {
+			continue
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%d\t%d\t%s\n", r.git, r.packs, r.objs-r.packs,
+				work, fullPath(sc, r.path))
+			continue
+		}
+		mark := ""
+		if r.git > work {
+			mark = " *"
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s|%12s|%12s|%12s| %s%s\n", i+1, fmtSz(sc, r.git),
+			fmtSz(sc, r.packs), fmtSz(sc, r.objs-r.packs), fmtSz(sc, work),
+			smartTruncate(r.path, sc.maxNameLen), mark)
+	}
+	if n > 0 && !sc.plain {
+		fmt.Fprintf(sc.out, tr("  * %d repositories whose .git is larger than the working tree\n"), n)
+	}
+}
@@ -0,0 +1,146 @@
+// +build linux freebsd darwin openbsd netbsd
+
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Reports of --deterministic scans of an in-memory tree, compared with
+ * the files of testdata. After a wanted change of the output, rewrite
+ * them with: go test -run Golden -update */
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+func goldenTree() mem_fs {
+	m := mem_fs{".": memDir(1, 1),
+		"home": memDir(1, 2), "home/joe": memDir(1, 3),
+		"home/joe/video.mkv": memFile(1, 4, 700<<20),
+		"home/joe/notes.txt": memFile(1, 5, 1200),
+		"home/ann":           memDir(1, 6),
+		"home/ann/a.iso":     memFile(1, 7, 40<<20),
+		"home/ann/b.iso":     memFile(1, 8, 40<<20), // same size, sorted by path
+		"home/ann/link.iso":  memLink(memFile(1, 9, 3<<20), 2),
+		"srv":                memDir(1, 10),
+		"srv/link.iso":       memLink(memFile(1, 9, 3<<20), 2),
+		"srv/db":             memFile(1, 11, 90<<20),
+		"empty":              memDir(1, 12),
+		"tmp":                memFile(1, 13, 0),
+		"mnt":                memDir(2, 1),
+		"mnt/data":           memFile(2, 2, 1<<30),
+	}
+	for i := 0; i < 30; i++ { // sampled with --estimate
+		d := fmt.Sprintf("srv/logs/%02d", i)
+		m[d] = memDir(1, uint64(100+2*i))
+		m[d+"/log"] = memFile(1, uint64(101+2*i), int64(i+1)<<20)
+	}
+	m["srv/logs"] = memDir(1, 99)
+	return m
+}
+
+/* Compares out with testdata/name.golden, or rewrites it with -update */
+func golden(t *testing.T, name string, out []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, want) {
+		t.Errorf("%s differs from %s:\n%s", name, path, out)
+	}
+}
+
+func TestGoldenReports(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(sc *s_scan)
+	}{
+		{"text", nil},
+		{"plain", func(sc *s_scan) { sc.plain, sc.humanReadable = true, false }},
+		{"depth", func(sc *s_scan) { sc.maxDepth = 3 }},
+		{"estimate", func(sc *s_scan) { sc.estimate = 0.3 }},
+		{"csv", func(sc *s_scan) { sc.reporter = reporters["csv"] }},
+		{"influx", func(sc *s_scan) { sc.reporter = reporters["influx"] }},
+		{"graphite", func(sc *s_scan) { sc.reporter = reporters["graphite"] }},
+		{"markdown", func(sc *s_scan) { sc.reporter = reporters["markdown"] }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			sc, fi, total := memScan(t, goldenTree(), func(sc *s_scan) {
+				sc.out, sc.rep = &out, &out
+				sc.deterministic, sc.humanReadable = true, true
+				sc.maxShownLines = dft_MAXSHOWNLINES
+				if tc.set != nil {
+					tc.set(sc)
+				}
+				getConsoleWidth(sc)
+			})
+			showResults(sc, fi, total)
+			golden(t, tc.name, out.Bytes())
+		})
+	}
+}
+
+func TestGoldenNcduExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.json")
+	sc, _, _ := memScan(t, goldenTree(), func(sc *s_scan) {
+		sc.export, sc.exportPath, sc.deterministic = true, path, true
+		ncduInit(sc)
+	})
+	ncduEnd(sc)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd() // name of the root in the export
+	golden(t, "ncdu", bytes.Replace(b, []byte(`"`+wd+`"`), []byte(`"/mem"`), 1))
+}
+
+/* The same tree gives the same sample, whatever the order of its
+ * directories and the other directories scanned before */
+func TestEstimateSample(t *testing.T) {
+	var first []string
+	for run := 0; run < 3; run++ {
+		tree := goldenTree()
+		for i := 0; i < run; i++ { // scanned first, with their own samples
+			d := fmt.Sprintf("a%d", i)
+			tree[d] = memDir(1, uint64(1000+i))
+		}
+		sc, _, _ := memScan(t, tree, func(sc *s_scan) {
+			sc.deterministic, sc.estimate = true, 0.3
+			sc.maxBigFiles = 100
+		})
+		var files []string
+		for _, f := range sc.bigfiles {
+			files = append(files, f.path)
+		}
+		if run == 0 {
+			first = files
+		} else if fmt.Sprint(files) != fmt.Sprint(first) {
+			t.Errorf("run %d scanned %q, then %q", run, first, files)
+		}
+	}
+}
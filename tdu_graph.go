@@ -0,0 +1,123 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Graph of the size tree in the DOT language of Graphviz (--dot), e.g.
+ * rendered with 'dot -Tsvg', and folded stacks (--folded) for
+ * flamegraph.pl or speedscope. The tree is the one of the text report:
+ * the depth 1 items and, with --depth, the largest entries of each of
+ * them. Items under 1% of the total are left out of the graph. */
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const dot_MINPERCENT = 1.0 // smaller items are not drawn
+
+func dotLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+func writeDotNode(sc *s_scan, w *bufio.Writer, id *int, parent int, f *file, total int64) {
+	du := shownSize(sc, f.diskUsage, f.size)
+	p := float64(du) * 100 / float64(total)
+	if p < dot_MINPERCENT {
+		return
+	}
+	*id++
+	n := *id
+	name := filepath.Base(f.path)
+	if f.isDir {
+		name += "/"
+	}
+	fmt.Fprintf(w, "  n%d [label=\"%s\\n%s\", fontsize=%.0f, fillcolor=\"0.6 %.2f 1\"];\n",
+		n, dotLabel(name), fmtSz(sc, du), 10+p/4, 0.1+p/125)
+	fmt.Fprintf(w, "  n%d -> n%d [penwidth=%.1f];\n", parent, n, 1+p/20)
+	for i := range f.kids {
+		writeDotNode(sc, w, id, n, &f.kids[i], total)
+	}
+}
+
+func writeDot(sc *s_scan, fi []file, total *file) {
+	if sc.dotPath == "" {
+		return
+	}
+	t := shownSize(sc, total.diskUsage, total.size)
+	f, err := os.Create(sc.dotPath)
+	if err == nil {
+		w := bufio.NewWriter(f)
+		fmt.Fprintln(w, "digraph tdu {")
+		fmt.Fprintln(w, "  rankdir=LR;")
+		fmt.Fprintln(w, "  node [shape=box, style=filled, fontname=\"Helvetica\"];")
+		fmt.Fprintf(w, "  n0 [label=\"%s\\n%s\", fontsize=36, fillcolor=\"0.6 0.9 1\"];\n",
+			dotLabel(sc.root), fmtSz(sc, t))
+		var id int
+		if t > 0 {
+			sortFiles(sc, fi)
+			for i := range fi {
+				writeDotNode(sc, w, &id, 0, &fi[i], t)
+			}
+		}
+		fmt.Fprintln(w, "}")
+		err = w.Flush()
+		if e := f.Close(); err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("  [ERROR] Cannot write graph: %v\n"), err)
+	}
+}
+
+/* One line per item: its path from the scanned directory, separated by
+ * semicolons, and the usage that is not in its listed entries */
+func writeFoldedNode(sc *s_scan, w *bufio.Writer, stack string, f *file) {
+	stack += ";" + strings.ReplaceAll(filepath.Base(f.path), ";", "_")
+	self := shownSize(sc, f.diskUsage, f.size)
+	for i := range f.kids {
+		k := &f.kids[i]
+		self -= shownSize(sc, k.diskUsage, k.size)
+		writeFoldedNode(sc, w, stack, k)
+	}
+	if self > 0 {
+		fmt.Fprintf(w, "%s %d\n", stack, self)
+	}
+}
+
+func writeFolded(sc *s_scan, fi []file, total *file) {
+	if sc.foldedPath == "" {
+		return
+	}
+	f, err := os.Create(sc.foldedPath)
+	if err == nil {
+		w := bufio.NewWriter(f)
+		root := strings.ReplaceAll(sc.root, ";", "_")
+		self := shownSize(sc, total.diskUsage, total.size)
+		for i := range fi {
+			self -= shownSize(sc, fi[i].diskUsage, fi[i].size)
+			writeFoldedNode(sc, w, root, &fi[i])
+		}
+		if self > 0 { // the scanned directory itself
+			fmt.Fprintf(w, "%s %d\n", root, self)
+		}
+		err = w.Flush()
+		if e := f.Close(); err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("  [ERROR] Cannot write graph: %v\n"), err)
+	}
+}
@@ -0,0 +1,108 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Each scan may append a statfs sample of the current partition to a
+ * JSON Lines file (--usage-log).  The history of samples gives the growth
+ * rate, which is used to predict when the filesystem will be full.
+ */
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+type usage_sample struct {
+	Timestamp     int64    `json:"timestamp"`
+	Filesystem    string   `json:"filesystem"`
+	Used          uint64   `json:"used"`
+	Avail         uint64   `json:"avail"`
+	GrowthPerDay  *float64 `json:"growth_per_day,omitempty"`
+	DaysUntilFull *float64 `json:"days_until_full,omitempty"`
+}
+
+func readSamples(path, fs string) []usage_sample {
+	var samples []usage_sample
+	f, err := os.Open(path)
+	if err != nil {
+		return samples // no history yet
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s usage_sample
+		if json.Unmarshal(scanner.Bytes(), &s) != nil {
+			continue // ignore corrupted lines
+		}
+		if s.Filesystem == fs {
+			samples = append(samples, s)
+		}
+	}
+	return samples
+}
+
+/* Least squares regression of used bytes over time, in bytes per day */
+func growthRate(samples []usage_sample) (float64, bool) {
+	n := float64(len(samples))
+	if n < 2 {
+		return 0, false
+	}
+	var st, su, stt, stu float64
+	for _, s := range samples {
+		t := float64(s.Timestamp-samples[0].Timestamp) / 86400
+		u := float64(s.Used)
+		st += t
+		su += u
+		stt += t * t
+		stu += t * u
+	}
+	d := n*stt - st*st
+	if d == 0 {
+		return 0, false
+	}
+	return (n*stu - st*su) / d, true
+}
+
+func projectUsage(sc *s_scan, used, avail uint64) {
+	if sc.usageLog == "" {
+		return
+	}
+	fs := sc.partition
+	if fs == "" {
+		fs = fmt.Sprintf("dev 0x%04X", sc.currentDevice)
+	}
+	now := usage_sample{Timestamp: time.Now().Unix(), Filesystem: fs,
+		Used: used, Avail: avail}
+	samples := append(readSamples(sc.usageLog, fs), now)
+	rate, ok := growthRate(samples)
+	if ok {
+		now.GrowthPerDay = &rate
+		fmt.Fprintf(sc.out, tr("  Growth  :%10s per day"), fmtSz(sc, int64(rate)))
+		if rate > 0 {
+			days := float64(avail) / rate
+			now.DaysUntilFull = &days
+			fmt.Fprintf(sc.out, tr(", full in %.0f days"), days)
+		}
+		fmt.Fprintf(sc.out, tr(" (%d samples)\n"), len(samples))
+	}
+	mode := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	f, err := os.OpenFile(sc.usageLog, mode, 0666)
+	if err != nil {
+		fmt.Fprintf(sc.out, tr("  [ERROR] Cannot write usage log: %v\n"), err)
+		return
+	}
+	defer f.Close()
+	b, _ := json.Marshal(now)
+	f.Write(append(b, '\n'))
+}
@@ -0,0 +1,181 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* With --history, a summary of each scan (the usage of the depth 1 items
+ * and the totals) is appended to a JSON Lines file in the XDG state
+ * directory. 'tdu history DIR' then shows the growth of DIR since the
+ * previous run and over the last runs, fastest growing items first. */
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const dft_HISTORYRUNS = 10 // runs shown by 'tdu history'
+
+type history_entry struct {
+	Timestamp int64            `json:"timestamp"`
+	Root      string           `json:"root"`
+	DiskUsage int64            `json:"disk_usage"`
+	Size      int64            `json:"size"`
+	Items     int64            `json:"items"`
+	Dirs      map[string]int64 `json:"dirs"` // disk usage of the depth 1 items
+}
+
+/* $XDG_STATE_HOME/tdu/history.jsonl, ~/.local/state by default */
+func historyPath() string {
+	d := os.Getenv("XDG_STATE_HOME")
+	if d == "" {
+		h, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		d = filepath.Join(h, ".local", "state")
+	}
+	return filepath.Join(d, "tdu", "history.jsonl")
+}
+
+func saveHistory(sc *s_scan, fi []file, total *file) {
+	if !sc.history || total == nil {
+		return
+	}
+	e := history_entry{Timestamp: time.Now().Unix(), Root: sc.root,
+		DiskUsage: total.diskUsage, Size: total.size, Items: total.items,
+		Dirs: make(map[string]int64, len(fi))}
+	for _, f := range fi {
+		e.Dirs[f.name] = f.diskUsage
+	}
+	p := historyPath()
+	err := os.MkdirAll(filepath.Dir(p), 0755)
+	if err == nil {
+		var f *os.File
+		f, err = os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err == nil {
+			b, _ := json.Marshal(e)
+			_, err = f.Write(append(b, '\n'))
+			if e := f.Close(); err == nil {
+				err = e
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(sc.out, tr("  [ERROR] Cannot write history: %v\n"), err)
+	}
+}
+
+func readHistory(root string) ([]history_entry, error) {
+	f, err := os.Open(historyPath())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var h []history_entry
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 16*1024*1024) // lines of directories with many items
+	for s.Scan() {
+		var e history_entry
+		if json.Unmarshal(s.Bytes(), &e) != nil {
+			continue // ignore corrupted lines
+		}
+		if e.Root == root {
+			h = append(h, e)
+		}
+	}
+	return h, s.Err()
+}
+
+func fmtGrowth(sc *s_scan, n int64) string {
+	if n > 0 {
+		return "+" + fmtSz(sc, n)
+	}
+	if n < 0 {
+		return "-" + fmtSz(sc, -n)
+	}
+	return "0"
+}
+
+/* 'tdu history [DIR]': growth of DIR over the last sc.historyRuns runs */
+func showHistory(sc *s_scan, args []string) {
+	root, err := changeDir(args)
+	if err != nil {
+		fmt.Fprintln(sc.out, err)
+		return
+	}
+	sc.root = root
+	h, err := readHistory(root)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(sc.out, tr("  [ERROR] Cannot read history: %v\n"), err)
+		return
+	}
+	if len(h) == 0 {
+		fmt.Fprintf(sc.out, tr("  No history of %s yet: scan it with --history.\n"), root)
+		return
+	}
+	if len(h) > sc.historyRuns {
+		h = h[len(h)-sc.historyRuns:]
+	}
+	fmt.Fprintln(sc.out, header("HISTORY"))
+	for i, e := range h {
+		var d int64
+		if i > 0 {
+			d = e.DiskUsage - h[i-1].DiskUsage
+		}
+		t := time.Unix(e.Timestamp, 0).Format("2006-01-02 15:04")
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%d\t%s\n", e.Timestamp, e.DiskUsage, d, root)
+			continue
+		}
+		fmt.Fprintf(sc.out, "  %s|%12s|%12s|\n", t, fmtSz(sc, e.DiskUsage), fmtGrowth(sc, d))
+	}
+	if len(h) < 2 {
+		return
+	}
+	first, prev, last := h[0], h[len(h)-2], h[len(h)-1]
+	type growth struct {
+		name       string
+		last, runs int64
+	}
+	var list []growth
+	for n, du := range last.Dirs {
+		if g := (growth{n, du - prev.Dirs[n], du - first.Dirs[n]}); g.last > 0 || g.runs > 0 {
+			list = append(list, g)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].last != list[j].last {
+			return list[i].last > list[j].last
+		}
+		return list[i].runs > list[j].runs
+	})
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("FASTEST GROWING ITEMS"))
+	if !sc.plain {
+		fmt.Fprintf(sc.out, "%15s|%14s|\n", tr("Previous run"),
+			fmt.Sprintf(tr("Last %d runs"), len(h)))
+	}
+	for i, g := range list {
+		if i >= sc.maxShownLines {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%s\n", g.last, g.runs, filepath.Join(root, g.name))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%11s|%14s| %s\n", i+1, fmtGrowth(sc, g.last),
+			fmtGrowth(sc, g.runs), smartTruncate(g.name, sc.maxNameLen))
+	}
+}
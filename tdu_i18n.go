@@ -0,0 +1,780 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Translation of the messages shown to the user.
+ * Catalogs are indexed by the original English message (with its format
+ * verbs and padding), missing entries are printed in English.
+ * Labels used as table columns keep the width of the English text. */
+
+package main
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+var catalog map[string]string // messages of the selected language
+
+var catalogs = map[string]map[string]string{
+	"fr": {
+		// Sections
+		"BIGGEST FILES":               "PLUS GROS FICHIERS",
+		"EMPTY DIRECTORIES":           "DOSSIERS VIDES",
+		"ACCESS DENIED":               "ACCÈS REFUSÉ",
+		"FILE STATUS ERROR":           "ERREURS DE STAT",
+		"LOCKED FILES":                "FICHIERS VERROUILLÉS",
+		"SOCKETS AND PIPES":           "SOCKETS ET TUBES",
+		"DEVICES":                     "PÉRIPHÉRIQUES",
+		"OTHER FILESYSTEMS":           "AUTRES SYST. DE FICHIERS",
+		"USAGE PER DEVICE":            "OCCUPATION PAR PÉRIPHÉRIQUE",
+		"ALL MOUNTS":                  "TOUS LES MONTAGES",
+		"LARGEST ITEMS OF ALL MOUNTS": "PLUS GROS ÉLÉMENTS DE TOUS LES MONTAGES",
+		"ALL DIRECTORIES":             "TOUS LES DOSSIERS",
+		"SKIPPED SNAPSHOTS":           "INSTANTANÉS IGNORÉS",
+		"SKIPPED DUPLICATE MOUNTS":    "MONTAGES EN DOUBLE IGNORÉS",
+		"BIGGEST XATTRS":              "PLUS GROS XATTRS",
+		"USAGE BY YEAR":               "OCCUPATION PAR ANNÉE",
+		"MOST ENTRIES":                "PLUS D'ENTRÉES",
+		"USAGE BY EXTENSION":          "OCCUPATION PAR EXTENSION",
+		"USAGE BY OWNER":              "OCCUPATION PAR PROPRIÉTAIRE",
+		"USAGE BY GROUP":              "OCCUPATION PAR GROUPE",
+		"USAGE BY AGE":                "OCCUPATION PAR ÂGE",
+		"SPARSE FILES":                "FICHIERS CREUX",
+		"COLD FILES (1 YEAR+)":        "FICHIERS FROIDS (1 AN+)",
+		"SHARED EXTENTS":              "EXTENTS PARTAGÉS",
+		"COMPRESSION":                 "COMPRESSION",
+		"DOCKER STORAGE":              "STOCKAGE DOCKER",
+		"LOGS":                        "JOURNAUX",
+		"LARGEST ROTATED LOGS":        "PLUS GROS JOURNAUX ARCHIVÉS",
+		"GROWING LOGS (LAST 24H)":     "JOURNAUX QUI GROSSISSENT (24H)",
+		"RECLAIMABLE SPACE":           "ESPACE RÉCUPÉRABLE",
+		"CACHE DIRECTORIES":           "DOSSIERS DE CACHE",
+		"IGNORED BY GIT":              "IGNORÉS PAR GIT",
+		"GIT REPOSITORIES":            "DÉPÔTS GIT",
+		"BUILD ARTIFACTS":             "PRODUITS DE COMPILATION",
+		"INODES":                      "INODES",
+		"UNRESPONSIVE PATHS":          "CHEMINS SANS RÉPONSE",
+		"ESTIMATE":                    "ESTIMATION",
+		"FIXED DRIVES":                "LECTEURS FIXES",
+		"HISTORY":                     "HISTORIQUE",
+		"FASTEST GROWING ITEMS":       "CROISSANCE LA PLUS RAPIDE",
+		"EMPTY FILES":                 "FICHIERS VIDES",
+		"DIRECTORIES OF TINY FILES":   "DOSSIERS DE PETITS FICHIERS",
+		"SLACK SPACE":                 "ESPACE PERDU",
+		"USAGE BY CATEGORY":           "OCCUPATION PAR CATÉGORIE",
+		"FILES OF DELETED USERS":      "FICHIERS D'UTILISATEURS SUPPRIMÉS",
+		"SETUID AND SETGID FILES":     "FICHIERS SETUID ET SETGID",
+		"WORLD-WRITABLE FILES":        "FICHIERS MODIFIABLES PAR TOUS",
+		"PODMAN STORAGE":              "STOCKAGE PODMAN",
+		"MOUNTED FILESYSTEMS":         "SYSTÈMES DE FICHIERS MONTÉS",
+		// Summary
+		"  Item: %d, Dir: %d, File: %d":  "  Elément: %d, Dossier: %d, Fichier: %d",
+		", Empty Dir: %d":                ", Dossier vide: %d",
+		", Symlink: %d":                  ", Lien symbolique: %d",
+		",\n  Hardlink: %d":              ",\n  Lien physique: %d",
+		", Socket: %d":                   ", Socket: %d",
+		"Denied: %d":                     "Refusé: %d",
+		", Error: %d":                    ", Erreur: %d",
+		", Locked: %d":                   ", Verrouillé: %d",
+		", Retry: %d":                    ", Nouvel essai: %d",
+		", Excluded: %d":                 ", Exclu: %d",
+		"Truncated: scan budget reached": "Tronqué: limite d'analyse atteinte",
+		", Block device: %d":             ", Périphérique bloc: %d",
+		", Character device: %d":         ", Périphérique caractère: %d",
+		", Depth: %d\n":                  ", Profondeur: %d\n",
+		"  Deepest: %s\n":                "  Le plus profond: %s\n",
+		"  Longest path (%d): %s\n":      "  Chemin le plus long (%d): %s\n",
+		"  Longest name (%d): %s":        "  Nom le plus long (%d): %s",
+		"  Total disk usage is zero.":    "  L'occupation disque totale est nulle.",
+		"  Total time: %.3f s\n\n":       "  Durée totale: %.3f s\n\n",
+		// Tables
+		"REMAINING":                              "RESTE",
+		"DISK SPACE":                             "ESPACE DISQUE",
+		"TOTAL SIZE":                             "TAILLE TOTALE",
+		"TOTAL":                                  "TOTAL",
+		"items":                                  "éléments",
+		"Disk":                                   "Disque",
+		"Apparent":                               "Apparente",
+		"Size":                                   "Taille",
+		"Used":                                   "Utilisé",
+		"Free":                                   "Libre",
+		"Mountpoint":                             "Point de montage",
+		"Left":                                   "Gauche",
+		"Right":                                  "Droite",
+		"Diff":                                   "Ecart",
+		"Name":                                   "Nom",
+		"  =%13s| %.02f%% of total disk usage\n": "  =%13s| %.02f%% de l'occupation disque totale\n",
+		"  =%13s| in all extended attributes\n":  "  =%13s| dans tous les attributs étendus\n",
+		"  Use --snapshots to include them.":     "  Utilisez --snapshots pour les inclure.",
+		"  Left : %s\n":                          "  Gauche: %s\n",
+		"  Right: %s\n":                          "  Droite: %s\n",
+		"    (%d more)\n":                        "    (%d de plus)\n",
+		"  Partition: %s":                        "  Partition: %s",
+		" Unknown FS Type 0x%04X":                " Type de FS inconnu 0x%04X",
+		"  Inodes  :%10d used (%2d%%) of %10d. Avail:%10d\n": "  Inodes  :%10d utilisés (%2d%%) sur %10d. Libres:%10d\n",
+		"  Size(%s):%9d used (%2d%%) of %10d. Avail:%10d\n":  "  Taille(%s):%9d utilisés (%2d%%) sur %10d. Libres:%10d\n",
+		"  Size    :%10s used (%2d%%) of %10s. Avail:%10s\n": "  Taille  :%10s utilisés (%2d%%) sur %10s. Libres:%10s\n",
+		"  Growth  :%10s per day":                            "  Hausse  :%10s par jour",
+		", full in %.0f days":                                ", plein dans %.0f jours",
+		" (%d samples)\n":                                    " (%d mesures)\n",
+		// Progress
+		"  Please wait...":                              "  Veuillez patienter...",
+		" scanning [%s]...\n":                           " analyse de [%s]...\n",
+		"  Scanning [%s]...\n":                          "  Analyse de [%s]...\n",
+		"  [.... scanning... ":                          "  [.... analyse... ",
+		"  Scan budget reached, not descending further": "  Limite atteinte, l'analyse s'arrête ici",
+		"  Unknown file type (%v): [%s]\n":              "  Type de fichier inconnu (%v): [%s]\n",
+		"  Not crossing FS boundary at %-15s %s":        "  Autre système de fichiers ignoré: %-15s %s",
+		"  Crossing FS boundary at %-15s %s":            "  Analyse d'un autre système de fichiers: %-15s %s",
+		"  Not crossing btrfs subvolume at %s":          "  Sous-volume btrfs ignoré: %s",
+		"  Not scanning snapshots at %s":                "  Instantanés ignorés: %s",
+		"  Not scanning %s (excluded by xattr)":         "  %s ignoré (exclu par xattr)",
+		// Errors
+		"  [ERROR] No local mountpoint found":                                      "  [ERREUR] Aucun point de montage local trouvé",
+		"\n  [ERROR] --max-bytes: %v\n\n":                                          "\n  [ERREUR] --max-bytes: %v\n\n",
+		"\n  [ERROR] --all-mounts takes no directory, export or manifest file\n\n": "\n  [ERREUR] --all-mounts n'accepte ni dossier, ni export, ni manifeste\n\n",
+		"\n  [ERROR] Cannot read baseline: %v\n\n":                                 "\n  [ERREUR] Lecture de la référence impossible: %v\n\n",
+		"\n  [ERROR] Cannot create output file: %v\n\n":                            "\n  [ERREUR] Création du fichier de sortie impossible: %v\n\n",
+		"\n  [ERROR] compare needs two directories and no export file\n\n":         "\n  [ERREUR] compare demande deux dossiers et aucun export\n\n",
+		"\n  [ERROR] Cannot open export file: %v\n\n":                              "\n  [ERREUR] Ouverture du fichier d'export impossible: %v\n\n",
+		"\n  [ERROR] Cannot create manifest: %v\n":                                 "\n  [ERREUR] Création du manifeste impossible: %v\n",
+		"\n  [ERROR] Cannot write manifest: %v\n":                                  "\n  [ERREUR] Ecriture du manifeste impossible: %v\n",
+		"  [ERROR] Cannot write usage log: %v\n":                                   "  [ERREUR] Ecriture du journal d'occupation impossible: %v\n",
+		"[TIP] Use double-quotes around the directory path if it contains spaces.": "[ASTUCE] Mettez le chemin entre guillemets s'il contient des espaces.",
+		"[TIP] Example: tdu.exe \"C:\\Program Files\"":                             "[ASTUCE] Exemple: tdu.exe \"C:\\Program Files\"",
+		// Help
+		" Usage: %s [options] [directory...]\n":                                                                                " Usage: %s [options] [dossier...]\n",
+		"        %s compare [options] DIR1 DIR2\n":                                                                             "        %s compare [options] DOSSIER1 DOSSIER2\n",
+		" Compiled with Go version %s":                                                                                         " Compilé avec Go version %s",
+		"Number of big files shown":                                                                                            "Nombre de gros fichiers affichés",
+		"Number of depth1 items shown":                                                                                         "Nombre d'éléments de premier niveau affichés",
+		"Number of empty directories shown (default 0)":                                                                        "Nombre de dossiers vides affichés (0 par défaut)",
+		"Number of access denied directories shown (default 0)":                                                                "Nombre de dossiers refusés affichés (0 par défaut)",
+		"Number of file status errors shown (default 0)":                                                                       "Nombre d'erreurs de stat affichées (0 par défaut)",
+		"Number of devices shown (default 0)":                                                                                  "Nombre de périphériques affichés (0 par défaut)",
+		"Number of files locked by other processes shown (Windows)":                                                            "Nombre de fichiers verrouillés affichés (Windows)",
+		"Number of files with the biggest extended attributes shown (Linux, default 0)":                                        "Nombre de fichiers aux plus gros attributs étendus affichés (Linux, 0 par défaut)",
+		"Number of sockets and named pipes shown (default 0)":                                                                  "Nombre de sockets et tubes nommés affichés (0 par défaut)",
+		"Export result to Ncdu's JSON format (- for stdout)":                                                                   "Exporter le résultat au format JSON de Ncdu (- pour stdout)",
+		"Show deepest and longest paths":                                                                                       "Afficher les chemins les plus profonds et les plus longs",
+		"Program info and usage":                                                                                               "Informations et usage du programme",
+		"Show the GNU General Public License V2":                                                                               "Afficher la licence GNU General Public License V2",
+		"Print sizes in human readable format.\nUse --human=false to print in kilobytes instead.":                              "Afficher les tailles en format lisible.\nUtilisez --human=false pour les afficher en kilo-octets.",
+		"Show both disk usage and apparent size columns":                                                                       "Afficher l'occupation disque et la taille apparente",
+		"Number of decimals in human readable sizes":                                                                           "Nombre de décimales des tailles lisibles",
+		"Color table rows by share of total disk usage.\nUse --color=false to disable colors.":                                 "Colorer les lignes selon leur part de l'occupation totale.\nUtilisez --color=false pour désactiver les couleurs.",
+		"Share of total disk usage (%) shown in red":                                                                           "Part de l'occupation totale (%) affichée en rouge",
+		"Share of total disk usage (%) shown in yellow":                                                                        "Part de l'occupation totale (%) affichée en jaune",
+		"Maximize console window (on Windows only)":                                                                            "Maximiser la fenêtre de la console (Windows uniquement)",
+		"Descend into other filesystems and tag their usage":                                                                   "Entrer dans les autres systèmes de fichiers et marquer leur usage",
+		"Write SHA-256, size, mtime and path of every file":                                                                    "Ecrire SHA-256, taille, date et chemin de chaque fichier",
+		"Do not hash files bigger than n MB (0: no limit)":                                                                     "Ne pas hacher les fichiers de plus de n Mo (0: sans limite)",
+		"Stop scanning after n items (0: no limit)":                                                                            "Arrêter l'analyse après n éléments (0: sans limite)",
+		"Stop scanning after this disk usage (e.g. 500M, 2G)":                                                                  "Arrêter l'analyse après cette occupation (ex. 500M, 2G)",
+		"Retries after a transient network filesystem error":                                                                   "Nouveaux essais après une erreur passagère du réseau",
+		"Milliseconds before the first retry, doubled each time":                                                               "Millisecondes avant le premier essai, doublées à chaque fois",
+		"Skip directories tagged with user.tdu.exclude=1\nor user.xdg.robots.{index,backup}=false (Linux)":                     "Ignorer les dossiers marqués user.tdu.exclude=1\nou user.xdg.robots.{index,backup}=false (Linux)",
+		"Show disk usage per modification year":                                                                                "Afficher l'occupation par année de modification",
+		"Scan each local mountpoint in turn":                                                                                   "Analyser chaque point de montage local",
+		"Scan / per mount, skipping /proc /sys /dev /run":                                                                      "Analyser / par montage, sans /proc /sys /dev /run",
+		"Scan snapper and timeshift snapshot directories":                                                                      "Analyser les instantanés de snapper et timeshift",
+		"Do not cross btrfs subvolume boundaries (Linux only)":                                                                 "Ne pas entrer dans les sous-volumes btrfs (Linux uniquement)",
+		"Append partition usage to a JSON Lines file\nand predict when the filesystem will be full":                            "Ajouter l'occupation à un fichier JSON Lines\net prévoir quand le système de fichiers sera plein",
+		"Also write the report to a file (without colors)":                                                                     "Ecrire aussi le rapport dans un fichier (sans couleurs)",
+		"Show growth since a previous Ncdu JSON export":                                                                        "Afficher la hausse depuis un export JSON Ncdu",
+		"Print raw bytes and full paths without padding\n(default when the output is not a terminal)":                          "Afficher octets bruts et chemins complets sans alignement\n(par défaut hors d'un terminal)",
+		"Stream scanned entries to stdout as JSON Lines\n(the report is then printed on stderr)":                               "Ecrire les éléments sur stdout en JSON Lines\n(le rapport est alors affiché sur stderr)",
+		"Language of the messages: en, fr, de (default from LANG)":                                                             "Langue des messages: en, fr, de (selon LANG par défaut)",
+		"Skip items matching a shell pattern (repeatable)":                                                                     "Ignorer les éléments correspondant à un motif (répétable)",
+		"Offer to delete the biggest files, after confirmation":                                                                "Proposer de supprimer les plus gros fichiers, après confirmation",
+		"  Number of the file to delete (Enter to quit): ":                                                                     "  Numéro du fichier à supprimer (Entrée pour quitter): ",
+		"  Delete %s (%s)? [y/N] ":                                                                                             "  Supprimer %s (%s) ? [y/N] ",
+		"  Deleted, but other hard links remain: no space freed.":                                                              "  Supprimé, mais d'autres liens physiques restent: aucun espace libéré.",
+		"  Freed %s, disk space is now %s\n":                                                                                   "  %s libérés, l'espace disque est maintenant de %s\n",
+		"Print the tables as":                                                                                                  "Afficher les tableaux en",
+		"  [ERROR] Cannot write report: %v\n":                                                                                  "  [ERREUR] Ecriture du rapport impossible: %v\n",
+		"\n  [ERROR] Unknown format %q (or used with --jsonl)\n\n":                                                             "\n  [ERREUR] Format %q inconnu (ou utilisé avec --jsonl)\n\n",
+		"Number of directory levels shown":                                                                                     "Nombre de niveaux de dossiers affichés",
+		"  =%13s| %.02f%% of total apparent size\n":                                                                            "  =%13s| %.02f%% de la taille apparente totale\n",
+		"Show and sort by apparent size instead of disk usage":                                                                 "Afficher et trier selon la taille apparente",
+		"Read --exclude patterns from a file (one per line)":                                                                   "Lire les motifs de --exclude depuis un fichier (un par ligne)",
+		"  [WARNING] Windows drive mounted with %s, stat is very slow here.\n  Running tdu.exe from Windows is much faster.\n": "  [ATTENTION] Lecteur Windows monté avec %s, stat y est très lent.\n  Lancer tdu.exe depuis Windows est bien plus rapide.\n",
+		"Keep watching the directory after the scan (Linux only)":                                                              "Continuer à surveiller le dossier après l'analyse (Linux uniquement)",
+		"\n  [ERROR] --watch only works on Linux, with a single directory, no --format nor --estimate\n\n":                     "\n  [ERREUR] --watch ne fonctionne que sous Linux, avec un seul dossier, sans --format ni --estimate\n\n",
+		"  [ERROR] Cannot watch: %v\n":                                                                                         "  [ERREUR] Surveillance impossible: %v\n",
+		"  [WARNING] %d directories not watched (see fs.inotify.max_user_watches)\n":                                           "  [ATTENTION] %d dossiers non surveillés (voir fs.inotify.max_user_watches)\n",
+		"  Watching [%s] at %s, press Ctrl+C to stop.\n\n":                                                                     "  Surveillance de [%s] à %s, Ctrl+C pour arrêter.\n\n",
+		"  [WARNING] Some events were lost, totals may be inaccurate.\n\n":                                                     "  [ATTENTION] Des événements ont été perdus, les totaux peuvent être inexacts.\n\n",
+		"Save the scan to a snapshot file":                                                                                     "Enregistrer l'analyse dans un fichier instantané",
+		"Show a saved snapshot instead of scanning":                                                                            "Afficher un instantané enregistré au lieu d'analyser",
+		"\n  [ERROR] --load, --import and --archive take no directory nor scan option\n\n":                                     "\n  [ERREUR] --load, --import et --archive n'acceptent ni dossier ni option d'analyse\n\n",
+		"\n  [ERROR] --save needs a single directory\n\n":                                                                      "\n  [ERREUR] --save demande un seul dossier\n\n",
+		"  [ERROR] Cannot save snapshot: %v\n":                                                                                 "  [ERREUR] Enregistrement de l'instantané impossible: %v\n",
+		"  [ERROR] Cannot load snapshot: %v\n":                                                                                 "  [ERREUR] Chargement de l'instantané impossible: %v\n",
+		"  OS: %s, loaded [%s] scanned on %s\n\n":                                                                              "  OS: %s, chargement de [%s] analysé le %s\n\n",
+		"Show an Ncdu JSON export instead of scanning":                                                                         "Afficher un export JSON Ncdu au lieu d'analyser",
+		"  [ERROR] Cannot import: %v\n":                                                                                        "  [ERREUR] Import impossible: %v\n",
+		"  Imported [%s] from %s\n\n":                                                                                          "  Import de [%s] depuis %s\n\n",
+		"\n  [ERROR] -o - cannot be used with --jsonl, --du or --format\n\n":                                                   "\n  [ERREUR] -o - ne peut pas être utilisé avec --jsonl, --du ou --format\n\n",
+		"Sort the table by size, items, name or apparent":                                                                      "Trier le tableau par size, items, name ou apparent",
+		"Reverse the order of the table":                                                                                       "Inverser l'ordre du tableau",
+		"\n  [ERROR] Unknown sort order %q\n\n":                                                                                "\n  [ERREUR] Ordre de tri %q inconnu\n\n",
+		"Number of directories with the most entries shown (default 0)":                                                        "Nombre de dossiers ayant le plus d'entrées affichés (0 par défaut)",
+		"Number of file extensions shown with their usage (default 0)":                                                         "Nombre d'extensions de fichiers affichées avec leur usage (0 par défaut)",
+		"(none)": "(aucune)",
+		"files":  "fichiers",
+		"Show disk usage per user and group (not on Windows)":                "Afficher l'occupation par utilisateur et groupe (pas sous Windows)",
+		"Show disk usage by age and the largest files unmodified for a year": "Afficher l'occupation par âge et les plus gros fichiers inchangés depuis un an",
+		"< 30 days": "< 30 jours",
+		"< 1 year":  "< 1 an",
+		"< 3 years": "< 3 ans",
+		"> 3 years": "> 3 ans",
+		"Only list files older than this (e.g. 180d, 2y)": "Ne lister que les fichiers plus anciens (ex. 180d, 2y)",
+		"Only list files newer than this (e.g. 12h, 7d)":  "Ne lister que les fichiers plus récents (ex. 12h, 7d)",
+		"\n  [ERROR] --%s: %v\n\n":                        "\n  [ERREUR] --%s: %v\n\n",
+		"Number of sparse files shown (default 0)":        "Nombre de fichiers creux affichés (défaut 0)",
+		", Sparse: %d":            ", Creux: %d",
+		"%3d.%12s| of %10s| %s\n": "%3d.%12s| sur %10s| %s\n",
+		"\n  [ERROR] Several directories cannot be scanned with an export, manifest, snapshot, graph or --format\n\n": "\n  [ERREUR] Plusieurs dossiers ne peuvent être analysés avec un export, manifeste, instantané, graphe ou --format\n\n",
+		"Use powers of 1000 (kB, MB, GB) instead of 1024 (KiB, MiB, GiB)":                                             "Utiliser des puissances de 1000 (kB, MB, GB) au lieu de 1024 (KiB, MiB, GiB)",
+		"Print sizes as exact byte counts":                             "Afficher les tailles en octets exacts",
+		"Print sizes in units of this size (e.g. 4K, 1M)":              "Afficher les tailles en unités de cette taille (ex. 4K, 1M)",
+		"\n  [ERROR] --bytes and -B cannot be used together\n\n":       "\n  [ERREUR] --bytes et -B ne peuvent être utilisés ensemble\n\n",
+		"\n  [ERROR] -B: invalid size: %q\n\n":                         "\n  [ERREUR] -B: taille invalide: %q\n\n",
+		"Print \"SIZE<tab>PATH\" lines in kilobytes like du -skx":      "Afficher des lignes \"TAILLE<tab>CHEMIN\" en kilo-octets comme du -skx",
+		"\n  [ERROR] --du cannot be used with --jsonl or --format\n\n": "\n  [ERREUR] --du ne peut pas être utilisé avec --jsonl ou --format\n\n",
+		"Show a bar of each item's usage in the table":                 "Afficher une barre de l'occupation de chaque élément dans le tableau",
+		" items, %s/s":         " éléments, %s/s",
+		", ~%d%% done, ETA %s": ", ~%d%% fait, reste %s",
+		"Quiet: only print the results, without title, progress nor partition info":   "Silencieux: n'afficher que les résultats, sans titre, progression ni partition",
+		"  Interrupted, showing the items scanned so far":                             "  Interrompu, affichage des éléments déjà analysés",
+		"Interrupted: partial results":                                                "Interrompu: résultats partiels",
+		"Show the content of a zip or tar archive instead of scanning":                "Afficher le contenu d'une archive zip ou tar au lieu d'analyser",
+		"  [ERROR] Cannot read archive: %v\n":                                         "  [ERREUR] Lecture de l'archive impossible: %v\n",
+		"  Content of archive %s\n\n":                                                 "  Contenu de l'archive %s\n\n",
+		"Show exclusive and shared extents of each item (Linux only)":                 "Afficher les extents exclusifs et partagés de chaque élément (Linux uniquement)",
+		"           Total|   Exclusive|      Shared|\n":                               "           Total|    Exclusif|     Partagé|\n",
+		"  =%13s|%12s|%12s| in all files\n":                                           "  =%13s|%12s|%12s| dans tous les fichiers\n",
+		"Show the compression ratio of each item (disk usage against apparent size)":  "Afficher le taux de compression de chaque élément (utilisation disque par rapport à la taille apparente)",
+		"      Disk usage|    Apparent| Ratio|\n":                                     "    Util. disque|   Apparente|  Taux|\n",
+		"  =%13s|%12s|%6s| in total\n":                                                "  =%13s|%12s|%6s| au total\n",
+		"  Quota   :%10s used (%2d%%) of %10s. Avail:%10s (user %s)\n":                "  Quota   :%10s utilisés (%2d%%) sur %10s. Libres:%10s (utilisateur %s)\n",
+		"  Quota   :%10s used (%2d%%) of %10s. Avail:%10s (group %s)\n":               "  Quota   :%10s utilisés (%2d%%) sur %10s. Libres:%10s (groupe %s)\n",
+		"List the mounted filesystems with their usage, like df":                      "Lister les systèmes de fichiers montés avec leur utilisation, comme df",
+		"\n  [ERROR] --mounts takes no directory, export or manifest file\n\n":        "\n  [ERREUR] --mounts n'accepte ni dossier, ni fichier d'export ou de manifeste\n\n",
+		"          Size|        Used|       Avail| Use%|IUse%| Type     Mountpoint\n": "        Taille|    Utilisés|      Libres| Uti%|IUti%| Type     Point de montage\n",
+		"Same as --all-mounts":                                                        "Identique à --all-mounts",
+		"Same as --tag-other-fs":                                                      "Identique à --tag-other-fs",
+		"Also cross into pseudo filesystems (proc, sysfs, tmpfs...)":                  "Traverser aussi les pseudo-systèmes de fichiers (proc, sysfs, tmpfs...)",
+		"  Skipping pseudo filesystem at %-15s %s":                                    "  Pseudo-système de fichiers ignoré à %-15s %s",
+		"bind mount of %s":       "montage lié de %s",
+		"overlay of %s":          "overlay de %s",
+		"  Not scanning %s (%s)": "  %s non analysé (%s)",
+		"  Not counted twice: %d bind or overlay mounts\n":                    "  Non comptés deux fois: %d montages liés ou overlay\n",
+		"Show the usage of each Docker or Podman image, container and volume": "Afficher l'utilisation de chaque image, conteneur et volume Docker ou Podman",
+		"(layers shared by several images)":                                   "(couches partagées par plusieurs images)",
+		"(unused layers, build cache)":                                        "(couches inutilisées, cache de build)",
+		"image":                                                               "image",
+		"container":                                                           "conteneur",
+		"volume":                                                              "volume",
+		"other":                                                               "autre",
+		"  =%13s| in %s\n":                                                    "  =%13s| dans %s\n",
+		"Show the usage of the systemd journal, the largest rotated logs and the growing ones": "Afficher l'utilisation du journal systemd, les plus gros journaux archivés et ceux qui grossissent",
+		"  Logs    :%12s in %s\n":       "  Journaux:%12s dans %s\n",
+		"  Journal :%12s in %d files\n": "  Journal :%12s dans %d fichiers\n",
+		"Show the space that cleaning package caches, user caches, trash and old kernels would free": "Afficher l'espace que libérerait le nettoyage des caches de paquets, des caches utilisateur, de la corbeille et des anciens noyaux",
+		"remove the kernel package":                                                                 "supprimer le paquet du noyau",
+		"  =%13s| could be freed, nothing was deleted\n":                                            "  =%13s| pourraient être libérés, rien n'a été supprimé\n",
+		"Show the usage of directories tagged with CACHEDIR.TAG apart":                              "Afficher à part l'utilisation des répertoires marqués par CACHEDIR.TAG",
+		"Skip directories tagged with CACHEDIR.TAG":                                                 "Ignorer les répertoires marqués par CACHEDIR.TAG",
+		"  Not scanning %s (cache directory)":                                                       "  %s ignoré (répertoire de cache)",
+		"  =%13s| in %d directories tagged with CACHEDIR.TAG\n":                                     "  =%13s| dans %d répertoires marqués par CACHEDIR.TAG\n",
+		"  Use --exclude-caches-all to skip them.":                                                  "  Utilisez --exclude-caches-all pour les ignorer.",
+		"Show the usage of items ignored by git apart, in git repositories":                         "Afficher à part l'utilisation des éléments ignorés par git, dans les dépôts git",
+		"Skip items ignored by git, in git repositories":                                            "Ignorer les éléments ignorés par git, dans les dépôts git",
+		"  =%13s| ignored in %d git repositories\n":                                                 "  =%13s| ignorés dans %d dépôts git\n",
+		"  Use --exclude-gitignored to skip them.":                                                  "  Utilisez --exclude-gitignored pour les ignorer.",
+		"Show the usage of each git repository, its history against its working tree":               "Afficher l'utilisation de chaque dépôt git, son historique face à sa copie de travail",
+		"            .git|       Packs|       Loose|Working tree|\n":                                "            .git|       Packs|   Non packé|Copie trav. |\n",
+		"  * %d repositories whose .git is larger than the working tree\n":                          "  * %d dépôts dont le .git est plus gros que la copie de travail\n",
+		"Show apart the usage of the build directories of a preset: dev":                            "Afficher à part l'utilisation des répertoires de compilation d'un préréglage : dev",
+		"Skip the directories of the --prune-preset":                                                "Ignorer les répertoires du --prune-preset",
+		"\n  [ERROR] Unknown prune preset %q\n\n":                                                   "\n  [ERREUR] Préréglage d'élagage %q inconnu\n\n",
+		"  =%13s| of build artifacts under these %d directories\n":                                  "  =%13s| de produits de compilation dans ces %d répertoires\n",
+		"  Use --prune to skip them.":                                                               "  Utilisez --prune pour les ignorer.",
+		"Show the inodes used by each item, against the inode table of the filesystem":              "Afficher les inodes utilisés par chaque élément, par rapport à la table d'inodes du système de fichiers",
+		"          Inodes| % of fs|\n":                                                              "          Inodes|  % du SF|\n",
+		"  =%13d|%8s| in total, of %d inodes\n":                                                     "  =%13d|%8s| au total, sur %d inodes\n",
+		"List world-writable, setuid and setgid files, and files of deleted users (not on Windows)": "Lister les fichiers modifiables par tous, setuid et setgid, et les fichiers d'utilisateurs supprimés (pas sous Windows)",
+		"%3d. uid %-8d|%12s|%9d files\n":                                                            "%3d. uid %-8d|%12s|%9d fichiers\n",
+		"Show disk usage per category of files: videos, images, archives...":                        "Afficher l'utilisation par catégorie de fichiers : vidéos, images, archives...",
+		"videos":    "vidéos",
+		"images":    "images",
+		"audio":     "audio",
+		"archives":  "archives",
+		"vm images": "images VM",
+		"documents": "documents",
+		"code":      "code",
+		"Show the space lost to the rounding of files to whole blocks":              "Afficher l'espace perdu par l'arrondi des fichiers à des blocs entiers",
+		"           Slack| % of du|     Files|\n":                                   "           Perte|  % util|  Fichiers|\n",
+		"  =%13s|%7.2f%%| of the total disk usage\n":                                "  =%13s|%7.2f%%| de l'utilisation totale\n",
+		"Number of zero-byte files and directories of tiny files shown (default 0)": "Nombre de fichiers vides et de répertoires de petits fichiers affichés (0 par défaut)",
+		"  %d zero-byte files in total\n":                                           "  %d fichiers vides au total\n",
+		"%3d.%9d of %9d files under 4 KiB| %s\n":                                    "%3d.%9d sur %9d fichiers < 4 KiB | %s\n",
+		"Write the size tree as a Graphviz DOT graph to a file":                     "Écrire l'arbre des tailles dans un fichier au format DOT de Graphviz",
+		"  [ERROR] Cannot write graph: %v\n":                                        "  [ERREUR] Ecriture du graphe impossible: %v\n",
+		"Write the size tree as folded stacks for flame graphs to a file":           "Écrire l'arbre des tailles en piles repliées pour flame graphs dans un fichier",
+		"Print the usage of each item in the InfluxDB line protocol":                "Afficher l'utilisation de chaque élément au format ligne d'InfluxDB",
+		"Print the usage of each item in the Graphite plaintext protocol":           "Afficher l'utilisation de chaque élément au format texte de Graphite",
+		"        %s history [options] [DIR]\n":                                      "        %s history [options] [DOSSIER]\n",
+		"Append a summary of the scan to the history shown by 'tdu history'":        "Ajouter un résumé de l'analyse à l'historique affiché par 'tdu history'",
+		"Number of runs shown by 'tdu history'":                                     "Nombre d'analyses affichées par 'tdu history'",
+		"\n  [ERROR] history needs at most one directory\n\n":                       "\n  [ERREUR] history accepte au plus un dossier\n\n",
+		"  [ERROR] Cannot write history: %v\n":                                      "  [ERREUR] Ecriture de l'historique impossible: %v\n",
+		"  [ERROR] Cannot read history: %v\n":                                       "  [ERREUR] Lecture de l'historique impossible: %v\n",
+		"  No history of %s yet: scan it with --history.\n":                         "  Pas encore d'historique de %s : analysez-le avec --history.\n",
+		"Previous run": "Préc. analyse",
+		"Last %d runs": "%d dernières",
+		"Write progress events as JSON Lines to stderr or to a file descriptor number": "Écrire la progression en JSON Lines sur stderr ou un descripteur de fichier",
+		"not stderr nor a file descriptor: %q":                                         "ni stderr ni un descripteur de fichier : %q",
+		"\n  [ERROR] Cannot write progress events: %v\n\n":                             "\n  [ERREUR] Impossible d'écrire la progression : %v\n\n",
+		"Print the results with a Go template file (text/template)":                    "Afficher les résultats avec un modèle Go (text/template)",
+		"\n  [ERROR] --template cannot be used with --jsonl or --format\n\n":           "\n  [ERREUR] --template ne peut pas être utilisé avec --jsonl ou --format\n\n",
+		"\n  [ERROR] Cannot read template: %v\n\n":                                     "\n  [ERREUR] Impossible de lire le modèle : %v\n\n",
+		"Print the main table, the biggest files and a summary as Markdown tables":     "Afficher le tableau principal, les plus gros fichiers et un résumé en tableaux Markdown",
+		"Item":          "Élément",
+		"Items":         "Éléments",
+		"Biggest files": "Plus gros fichiers",
+		"File":          "Fichier",
+		"Summary":       "Résumé",
+		"Disk usage":    "Espace disque",
+		"Apparent size": "Taille apparente",
+		"Directories":   "Dossiers",
+		"Files":         "Fichiers",
+		"Denied":        "Refusés",
+		"Errors":        "Erreurs",
+		"Scan time":     "Durée du scan",
+		"\n  [STATUS] %d items, %s, %d errors, %d denied, depth %d, %.1f s\n  in %s\n":      "\n  [ÉTAT] %d éléments, %s, %d erreurs, %d refusés, profondeur %d, %.1f s\n  dans %s\n",
+		"Stop scanning after this duration (e.g. 90s, 10m)":                                 "Arrêter l'analyse après cette durée (ex. 90s, 10m)",
+		"  Scan timeout reached, not descending further":                                    "  Délai atteint, l'analyse s'arrête ici",
+		"Skip a path that does not answer in this time, like a dead NFS mount (0: wait)":    "Ignorer un chemin sans réponse après cette durée, comme un montage NFS mort (0 : attendre)",
+		"  Skipped after %v without response (--stat-timeout)\n":                            "  Ignorés après %v sans réponse (--stat-timeout)\n",
+		"Only scan this fraction of the subdirectories (e.g. 0.1) and extrapolate":          "N'analyser que cette fraction des sous-dossiers (ex. 0.1) et extrapoler",
+		"\n  [ERROR] --estimate takes a fraction between 0 and 1\n\n":                       "\n  [ERREUR] --estimate attend une fraction entre 0 et 1\n\n",
+		"  =%13s| ±%10s| at 95%% confidence\n":                                              "  =%13s| ±%10s| avec 95%% de confiance\n",
+		"  %d of %d subdirectories scanned (%.1f%%), the other lists only cover them.\n":    "  %d sous-dossiers analysés sur %d (%.1f%%), les autres listes ne couvrent qu'eux.\n",
+		"Same output for the same tree: ties sorted by path, no times, width of 80 columns": "Même sortie pour la même arborescence : égalités triées par chemin, sans durées, largeur de 80 colonnes",
+		"List the fixed drives with their size and free space (Windows)":                    "Liste les lecteurs fixes avec leur taille et leur espace libre (Windows)",
+		"\n  [ERROR] --drives only works on Windows, see --mounts\n\n":                      "\n  [ERREUR] --drives ne fonctionne que sous Windows, voir --mounts\n\n",
+		"  [ERROR] No fixed drive found":                                                    "  [ERREUR] Aucun lecteur fixe trouvé",
+		"          Size|        Used|        Free| Use%| Type     Drive\n":                  "        Taille|    Utilisés|      Libres| Uti%| Type     Lecteur\n",
+		"  Scan them all with --all-mounts, or some with: tdu C:\\ D:\\":                    "  Analysez-les tous avec --all-mounts, ou certains avec : tdu C:\\ D:\\",
+		"  [ERROR] Cannot scan [%s]: %v\n":                                                  "  [ERREUR] Analyse de [%s] impossible: %v\n",
+		"\n  [ERROR] --enable-delete cannot be used with --plain\n\n":                       "\n  [ERREUR] --enable-delete ne peut pas être utilisé avec --plain\n\n",
+	},
+	"de": {
+		// Sections
+		"BIGGEST FILES":               "GRÖSSTE DATEIEN",
+		"EMPTY DIRECTORIES":           "LEERE VERZEICHNISSE",
+		"ACCESS DENIED":               "ZUGRIFF VERWEIGERT",
+		"FILE STATUS ERROR":           "STATUSFEHLER",
+		"LOCKED FILES":                "GESPERRTE DATEIEN",
+		"SOCKETS AND PIPES":           "SOCKETS UND PIPES",
+		"DEVICES":                     "GERÄTE",
+		"OTHER FILESYSTEMS":           "ANDERE DATEISYSTEME",
+		"USAGE PER DEVICE":            "BELEGUNG NACH GERÄT",
+		"ALL MOUNTS":                  "ALLE EINHÄNGEPUNKTE",
+		"LARGEST ITEMS OF ALL MOUNTS": "GRÖSSTE ELEMENTE ALLER EINHÄNGEPUNKTE",
+		"ALL DIRECTORIES":             "ALLE VERZEICHNISSE",
+		"SKIPPED SNAPSHOTS":           "ÜBERSPRUNGENE SNAPSHOTS",
+		"SKIPPED DUPLICATE MOUNTS":    "ÜBERSPRUNGENE DOPPELTE EINHÄNGEPUNKTE",
+		"BIGGEST XATTRS":              "GRÖSSTE XATTRS",
+		"USAGE BY YEAR":               "BELEGUNG NACH JAHR",
+		"MOST ENTRIES":                "MEISTE EINTRÄGE",
+		"USAGE BY EXTENSION":          "BELEGUNG NACH ENDUNG",
+		"USAGE BY OWNER":              "BELEGUNG NACH BESITZER",
+		"USAGE BY GROUP":              "BELEGUNG NACH GRUPPE",
+		"USAGE BY AGE":                "BELEGUNG NACH ALTER",
+		"SPARSE FILES":                "SPARSE-DATEIEN",
+		"MOUNTED FILESYSTEMS":         "EINGEHÄNGTE DATEISYSTEME",
+		"COLD FILES (1 YEAR+)":        "KALTE DATEIEN (1 JAHR+)",
+		"SHARED EXTENTS":              "GETEILTE EXTENTS",
+		"COMPRESSION":                 "KOMPRESSION",
+		"DOCKER STORAGE":              "DOCKER-SPEICHER",
+		"LOGS":                        "LOGS",
+		"LARGEST ROTATED LOGS":        "GRÖSSTE ROTIERTE LOGS",
+		"GROWING LOGS (LAST 24H)":     "WACHSENDE LOGS (LETZTE 24H)",
+		"RECLAIMABLE SPACE":           "FREIGEBBARER PLATZ",
+		"CACHE DIRECTORIES":           "CACHE-VERZEICHNISSE",
+		"IGNORED BY GIT":              "VON GIT IGNORIERT",
+		"GIT REPOSITORIES":            "GIT-REPOSITORYS",
+		"BUILD ARTIFACTS":             "BUILD-ARTEFAKTE",
+		"INODES":                      "INODES",
+		"UNRESPONSIVE PATHS":          "PFADE OHNE ANTWORT",
+		"ESTIMATE":                    "SCHÄTZUNG",
+		"FIXED DRIVES":                "FESTE LAUFWERKE",
+		"HISTORY":                     "VERLAUF",
+		"FASTEST GROWING ITEMS":       "AM SCHNELLSTEN WACHSEND",
+		"EMPTY FILES":                 "LEERE DATEIEN",
+		"DIRECTORIES OF TINY FILES":   "VERZEICHNISSE WINZIGER DATEIEN",
+		"SLACK SPACE":                 "VERSCHNITT",
+		"USAGE BY CATEGORY":           "BELEGUNG NACH KATEGORIE",
+		"FILES OF DELETED USERS":      "DATEIEN GELÖSCHTER BENUTZER",
+		"SETUID AND SETGID FILES":     "SETUID- UND SETGID-DATEIEN",
+		"WORLD-WRITABLE FILES":        "FÜR ALLE BESCHREIBBARE DATEIEN",
+		"PODMAN STORAGE":              "PODMAN-SPEICHER",
+		// Summary
+		"  Item: %d, Dir: %d, File: %d":  "  Element: %d, Verzeichnis: %d, Datei: %d",
+		", Empty Dir: %d":                ", Leeres Verz.: %d",
+		", Symlink: %d":                  ", Symlink: %d",
+		",\n  Hardlink: %d":              ",\n  Hardlink: %d",
+		", Socket: %d":                   ", Socket: %d",
+		"Denied: %d":                     "Verweigert: %d",
+		", Error: %d":                    ", Fehler: %d",
+		", Locked: %d":                   ", Gesperrt: %d",
+		", Retry: %d":                    ", Wiederholung: %d",
+		", Excluded: %d":                 ", Ausgeschlossen: %d",
+		"Truncated: scan budget reached": "Abgebrochen: Scan-Limit erreicht",
+		", Block device: %d":             ", Blockgerät: %d",
+		", Character device: %d":         ", Zeichengerät: %d",
+		", Depth: %d\n":                  ", Tiefe: %d\n",
+		"  Deepest: %s\n":                "  Am tiefsten: %s\n",
+		"  Longest path (%d): %s\n":      "  Längster Pfad (%d): %s\n",
+		"  Longest name (%d): %s":        "  Längster Name (%d): %s",
+		"  Total disk usage is zero.":    "  Die gesamte Belegung ist null.",
+		"  Total time: %.3f s\n\n":       "  Gesamtzeit: %.3f s\n\n",
+		// Tables
+		"REMAINING":                              "REST",
+		"DISK SPACE":                             "BELEGT",
+		"TOTAL SIZE":                             "GESAMTGRÖSSE",
+		"TOTAL":                                  "SUMME",
+		"items":                                  "Elemente",
+		"Disk":                                   "Belegt",
+		"Apparent":                               "Scheinbar",
+		"Size":                                   "Größe",
+		"Used":                                   "Belegt",
+		"Free":                                   "Frei",
+		"Mountpoint":                             "Einhängepunkt",
+		"Left":                                   "Links",
+		"Right":                                  "Rechts",
+		"Diff":                                   "Differenz",
+		"Name":                                   "Name",
+		"  =%13s| %.02f%% of total disk usage\n": "  =%13s| %.02f%% der gesamten Belegung\n",
+		"  =%13s| in all extended attributes\n":  "  =%13s| in allen erweiterten Attributen\n",
+		"  Use --snapshots to include them.":     "  Mit --snapshots werden sie einbezogen.",
+		"  Left : %s\n":                          "  Links : %s\n",
+		"  Right: %s\n":                          "  Rechts: %s\n",
+		"    (%d more)\n":                        "    (%d weitere)\n",
+		"  Partition: %s":                        "  Partition: %s",
+		" Unknown FS Type 0x%04X":                " Unbekannter FS-Typ 0x%04X",
+		"  Inodes  :%10d used (%2d%%) of %10d. Avail:%10d\n": "  Inodes  :%10d belegt (%2d%%) von %10d. Frei:%10d\n",
+		"  Size(%s):%9d used (%2d%%) of %10d. Avail:%10d\n":  "  Größe(%s):%9d belegt (%2d%%) von %10d. Frei:%10d\n",
+		"  Size    :%10s used (%2d%%) of %10s. Avail:%10s\n": "  Größe   :%10s belegt (%2d%%) von %10s. Frei:%10s\n",
+		"  Growth  :%10s per day":                            "  Zuwachs :%10s pro Tag",
+		", full in %.0f days":                                ", voll in %.0f Tagen",
+		" (%d samples)\n":                                    " (%d Messungen)\n",
+		// Progress
+		"  Please wait...":                              "  Bitte warten...",
+		" scanning [%s]...\n":                           " durchsuche [%s]...\n",
+		"  Scanning [%s]...\n":                          "  Durchsuche [%s]...\n",
+		"  [.... scanning... ":                          "  [.... durchsuche... ",
+		"  Scan budget reached, not descending further": "  Scan-Limit erreicht, Suche wird beendet",
+		"  Unknown file type (%v): [%s]\n":              "  Unbekannter Dateityp (%v): [%s]\n",
+		"  Not crossing FS boundary at %-15s %s":        "  Anderes Dateisystem übersprungen: %-15s %s",
+		"  Crossing FS boundary at %-15s %s":            "  Durchsuche anderes Dateisystem: %-15s %s",
+		"  Not crossing btrfs subvolume at %s":          "  btrfs-Subvolume übersprungen: %s",
+		"  Not scanning snapshots at %s":                "  Snapshots übersprungen: %s",
+		"  Not scanning %s (excluded by xattr)":         "  %s übersprungen (per xattr ausgeschlossen)",
+		// Errors
+		"  [ERROR] No local mountpoint found":                                      "  [FEHLER] Kein lokaler Einhängepunkt gefunden",
+		"\n  [ERROR] --max-bytes: %v\n\n":                                          "\n  [FEHLER] --max-bytes: %v\n\n",
+		"\n  [ERROR] --all-mounts takes no directory, export or manifest file\n\n": "\n  [FEHLER] --all-mounts erlaubt kein Verzeichnis, Export oder Manifest\n\n",
+		"\n  [ERROR] Cannot read baseline: %v\n\n":                                 "\n  [FEHLER] Referenz kann nicht gelesen werden: %v\n\n",
+		"\n  [ERROR] Cannot create output file: %v\n\n":                            "\n  [FEHLER] Ausgabedatei kann nicht erstellt werden: %v\n\n",
+		"\n  [ERROR] compare needs two directories and no export file\n\n":         "\n  [FEHLER] compare braucht zwei Verzeichnisse und keinen Export\n\n",
+		"\n  [ERROR] Cannot open export file: %v\n\n":                              "\n  [FEHLER] Exportdatei kann nicht geöffnet werden: %v\n\n",
+		"\n  [ERROR] Cannot create manifest: %v\n":                                 "\n  [FEHLER] Manifest kann nicht erstellt werden: %v\n",
+		"\n  [ERROR] Cannot write manifest: %v\n":                                  "\n  [FEHLER] Manifest kann nicht geschrieben werden: %v\n",
+		"  [ERROR] Cannot write usage log: %v\n":                                   "  [FEHLER] Belegungsprotokoll kann nicht geschrieben werden: %v\n",
+		"[TIP] Use double-quotes around the directory path if it contains spaces.": "[TIPP] Setzen Sie den Pfad in Anführungszeichen, wenn er Leerzeichen enthält.",
+		"[TIP] Example: tdu.exe \"C:\\Program Files\"":                             "[TIPP] Beispiel: tdu.exe \"C:\\Program Files\"",
+		// Help
+		" Usage: %s [options] [directory...]\n":                                                                                " Aufruf: %s [Optionen] [Verzeichnis...]\n",
+		"        %s compare [options] DIR1 DIR2\n":                                                                             "         %s compare [Optionen] VERZ1 VERZ2\n",
+		" Compiled with Go version %s":                                                                                         " Kompiliert mit Go Version %s",
+		"Number of big files shown":                                                                                            "Anzahl der angezeigten großen Dateien",
+		"Number of depth1 items shown":                                                                                         "Anzahl der angezeigten Elemente der ersten Ebene",
+		"Number of empty directories shown (default 0)":                                                                        "Anzahl der angezeigten leeren Verzeichnisse (Standard 0)",
+		"Number of access denied directories shown (default 0)":                                                                "Anzahl der angezeigten verweigerten Verzeichnisse (Standard 0)",
+		"Number of file status errors shown (default 0)":                                                                       "Anzahl der angezeigten Statusfehler (Standard 0)",
+		"Number of devices shown (default 0)":                                                                                  "Anzahl der angezeigten Geräte (Standard 0)",
+		"Number of files locked by other processes shown (Windows)":                                                            "Anzahl der angezeigten gesperrten Dateien (Windows)",
+		"Number of files with the biggest extended attributes shown (Linux, default 0)":                                        "Anzahl der Dateien mit den größten erweiterten Attributen (Linux, Standard 0)",
+		"Number of sockets and named pipes shown (default 0)":                                                                  "Anzahl der angezeigten Sockets und Named Pipes (Standard 0)",
+		"Export result to Ncdu's JSON format (- for stdout)":                                                                   "Ergebnis im JSON-Format von Ncdu exportieren (- für stdout)",
+		"Show deepest and longest paths":                                                                                       "Tiefste und längste Pfade anzeigen",
+		"Program info and usage":                                                                                               "Programminfo und Aufruf",
+		"Show the GNU General Public License V2":                                                                               "Die GNU General Public License V2 anzeigen",
+		"Print sizes in human readable format.\nUse --human=false to print in kilobytes instead.":                              "Größen lesbar anzeigen.\nMit --human=false werden Kilobytes angezeigt.",
+		"Show both disk usage and apparent size columns":                                                                       "Belegung und scheinbare Größe anzeigen",
+		"Number of decimals in human readable sizes":                                                                           "Anzahl der Nachkommastellen lesbarer Größen",
+		"Color table rows by share of total disk usage.\nUse --color=false to disable colors.":                                 "Tabellenzeilen nach Anteil an der Belegung färben.\nMit --color=false werden Farben abgeschaltet.",
+		"Share of total disk usage (%) shown in red":                                                                           "Anteil an der Belegung (%), der rot angezeigt wird",
+		"Share of total disk usage (%) shown in yellow":                                                                        "Anteil an der Belegung (%), der gelb angezeigt wird",
+		"Maximize console window (on Windows only)":                                                                            "Konsolenfenster maximieren (nur Windows)",
+		"Descend into other filesystems and tag their usage":                                                                   "Andere Dateisysteme durchsuchen und ihre Belegung markieren",
+		"Write SHA-256, size, mtime and path of every file":                                                                    "SHA-256, Größe, Änderungszeit und Pfad jeder Datei schreiben",
+		"Do not hash files bigger than n MB (0: no limit)":                                                                     "Dateien über n MB nicht hashen (0: keine Grenze)",
+		"Stop scanning after n items (0: no limit)":                                                                            "Suche nach n Elementen beenden (0: keine Grenze)",
+		"Stop scanning after this disk usage (e.g. 500M, 2G)":                                                                  "Suche nach dieser Belegung beenden (z.B. 500M, 2G)",
+		"Retries after a transient network filesystem error":                                                                   "Wiederholungen nach vorübergehenden Netzwerkfehlern",
+		"Milliseconds before the first retry, doubled each time":                                                               "Millisekunden vor der ersten Wiederholung, jeweils verdoppelt",
+		"Skip directories tagged with user.tdu.exclude=1\nor user.xdg.robots.{index,backup}=false (Linux)":                     "Verzeichnisse mit user.tdu.exclude=1 oder\nuser.xdg.robots.{index,backup}=false überspringen (Linux)",
+		"Show disk usage per modification year":                                                                                "Belegung pro Änderungsjahr anzeigen",
+		"Scan each local mountpoint in turn":                                                                                   "Jeden lokalen Einhängepunkt durchsuchen",
+		"Scan / per mount, skipping /proc /sys /dev /run":                                                                      "/ pro Einhängepunkt durchsuchen, ohne /proc /sys /dev /run",
+		"Scan snapper and timeshift snapshot directories":                                                                      "Snapshots von snapper und timeshift durchsuchen",
+		"Do not cross btrfs subvolume boundaries (Linux only)":                                                                 "btrfs-Subvolumes nicht durchsuchen (nur Linux)",
+		"Append partition usage to a JSON Lines file\nand predict when the filesystem will be full":                            "Belegung an eine JSON-Lines-Datei anhängen\nund vorhersagen, wann das Dateisystem voll ist",
+		"Also write the report to a file (without colors)":                                                                     "Bericht auch in eine Datei schreiben (ohne Farben)",
+		"Show growth since a previous Ncdu JSON export":                                                                        "Zuwachs seit einem früheren Ncdu-JSON-Export anzeigen",
+		"Print raw bytes and full paths without padding\n(default when the output is not a terminal)":                          "Rohe Bytes und volle Pfade ohne Ausrichtung ausgeben\n(Standard, wenn die Ausgabe kein Terminal ist)",
+		"Stream scanned entries to stdout as JSON Lines\n(the report is then printed on stderr)":                               "Einträge als JSON Lines auf stdout ausgeben\n(der Bericht erscheint dann auf stderr)",
+		"Language of the messages: en, fr, de (default from LANG)":                                                             "Sprache der Meldungen: en, fr, de (Standard aus LANG)",
+		"Skip items matching a shell pattern (repeatable)":                                                                     "Elemente überspringen, die einem Muster entsprechen (wiederholbar)",
+		"Offer to delete the biggest files, after confirmation":                                                                "Löschen der größten Dateien nach Bestätigung anbieten",
+		"  Number of the file to delete (Enter to quit): ":                                                                     "  Nummer der zu löschenden Datei (Enter zum Beenden): ",
+		"  Delete %s (%s)? [y/N] ":                                                                                             "  %s (%s) löschen? [y/N] ",
+		"  Deleted, but other hard links remain: no space freed.":                                                              "  Gelöscht, aber andere Hardlinks bleiben: kein Platz frei geworden.",
+		"  Freed %s, disk space is now %s\n":                                                                                   "  %s freigegeben, die Belegung beträgt jetzt %s\n",
+		"Print the tables as":                                                                                                  "Tabellen ausgeben als",
+		"  [ERROR] Cannot write report: %v\n":                                                                                  "  [FEHLER] Bericht kann nicht geschrieben werden: %v\n",
+		"\n  [ERROR] Unknown format %q (or used with --jsonl)\n\n":                                                             "\n  [FEHLER] Unbekanntes Format %q (oder mit --jsonl verwendet)\n\n",
+		"Number of directory levels shown":                                                                                     "Anzahl der angezeigten Verzeichnisebenen",
+		"  =%13s| %.02f%% of total apparent size\n":                                                                            "  =%13s| %.02f%% der gesamten scheinbaren Größe\n",
+		"Show and sort by apparent size instead of disk usage":                                                                 "Nach scheinbarer Größe statt Belegung anzeigen und sortieren",
+		"Read --exclude patterns from a file (one per line)":                                                                   "Muster für --exclude aus einer Datei lesen (eines pro Zeile)",
+		"  [WARNING] Windows drive mounted with %s, stat is very slow here.\n  Running tdu.exe from Windows is much faster.\n": "  [WARNUNG] Windows-Laufwerk mit %s eingehängt, stat ist hier sehr langsam.\n  tdu.exe unter Windows auszuführen ist viel schneller.\n",
+		"Keep watching the directory after the scan (Linux only)":                                                              "Verzeichnis nach der Analyse weiter überwachen (nur Linux)",
+		"\n  [ERROR] --watch only works on Linux, with a single directory, no --format nor --estimate\n\n":                     "\n  [FEHLER] --watch funktioniert nur unter Linux, mit einem Verzeichnis, ohne --format und --estimate\n\n",
+		"  [ERROR] Cannot watch: %v\n":                                                                                         "  [FEHLER] Überwachung nicht möglich: %v\n",
+		"  [WARNING] %d directories not watched (see fs.inotify.max_user_watches)\n":                                           "  [WARNUNG] %d Verzeichnisse nicht überwacht (siehe fs.inotify.max_user_watches)\n",
+		"  Watching [%s] at %s, press Ctrl+C to stop.\n\n":                                                                     "  Überwache [%s] um %s, Strg+C zum Beenden.\n\n",
+		"  [WARNING] Some events were lost, totals may be inaccurate.\n\n":                                                     "  [WARNUNG] Ereignisse gingen verloren, die Summen können ungenau sein.\n\n",
+		"Save the scan to a snapshot file":                                                                                     "Analyse in einer Snapshot-Datei speichern",
+		"Show a saved snapshot instead of scanning":                                                                            "Gespeicherten Snapshot anzeigen statt zu analysieren",
+		"\n  [ERROR] --load, --import and --archive take no directory nor scan option\n\n":                                     "\n  [FEHLER] --load, --import und --archive akzeptieren weder Verzeichnis noch Analyseoption\n\n",
+		"\n  [ERROR] --save needs a single directory\n\n":                                                                      "\n  [FEHLER] --save braucht ein einzelnes Verzeichnis\n\n",
+		"  [ERROR] Cannot save snapshot: %v\n":                                                                                 "  [FEHLER] Snapshot kann nicht gespeichert werden: %v\n",
+		"  [ERROR] Cannot load snapshot: %v\n":                                                                                 "  [FEHLER] Snapshot kann nicht geladen werden: %v\n",
+		"  OS: %s, loaded [%s] scanned on %s\n\n":                                                                              "  OS: %s, geladen [%s] analysiert am %s\n\n",
+		"Show an Ncdu JSON export instead of scanning":                                                                         "Ncdu-JSON-Export anzeigen statt zu analysieren",
+		"  [ERROR] Cannot import: %v\n":                                                                                        "  [FEHLER] Import nicht möglich: %v\n",
+		"  Imported [%s] from %s\n\n":                                                                                          "  [%s] importiert aus %s\n\n",
+		"\n  [ERROR] -o - cannot be used with --jsonl, --du or --format\n\n":                                                   "\n  [FEHLER] -o - kann nicht mit --jsonl, --du oder --format verwendet werden\n\n",
+		"Sort the table by size, items, name or apparent":                                                                      "Tabelle sortieren nach size, items, name oder apparent",
+		"Reverse the order of the table":                                                                                       "Reihenfolge der Tabelle umkehren",
+		"\n  [ERROR] Unknown sort order %q\n\n":                                                                                "\n  [FEHLER] Unbekannte Sortierung %q\n\n",
+		"Number of directories with the most entries shown (default 0)":                                                        "Anzahl angezeigter Verzeichnisse mit den meisten Einträgen (Standard 0)",
+		"Number of file extensions shown with their usage (default 0)":                                                         "Anzahl angezeigter Dateiendungen mit ihrer Belegung (Standard 0)",
+		"(none)": "(keine)",
+		"files":  "Dateien",
+		"Show disk usage per user and group (not on Windows)":                "Belegung pro Benutzer und Gruppe anzeigen (nicht unter Windows)",
+		"Show disk usage by age and the largest files unmodified for a year": "Belegung nach Alter und die größten seit einem Jahr unveränderten Dateien anzeigen",
+		"< 30 days": "< 30 Tage",
+		"< 1 year":  "< 1 Jahr",
+		"< 3 years": "< 3 Jahre",
+		"> 3 years": "> 3 Jahre",
+		"Only list files older than this (e.g. 180d, 2y)": "Nur Dateien älter als dies auflisten (z.B. 180d, 2y)",
+		"Only list files newer than this (e.g. 12h, 7d)":  "Nur Dateien neuer als dies auflisten (z.B. 12h, 7d)",
+		"\n  [ERROR] --%s: %v\n\n":                        "\n  [FEHLER] --%s: %v\n\n",
+		"Number of sparse files shown (default 0)":        "Anzahl der angezeigten Sparse-Dateien (Standard 0)",
+		", Sparse: %d":            ", Sparse: %d",
+		"%3d.%12s| of %10s| %s\n": "%3d.%12s| von %10s| %s\n",
+		"\n  [ERROR] Several directories cannot be scanned with an export, manifest, snapshot, graph or --format\n\n": "\n  [FEHLER] Mehrere Verzeichnisse können nicht mit Export, Manifest, Snapshot, Graph oder --format durchsucht werden\n\n",
+		"Use powers of 1000 (kB, MB, GB) instead of 1024 (KiB, MiB, GiB)":                                             "Potenzen von 1000 (kB, MB, GB) statt 1024 (KiB, MiB, GiB) verwenden",
+		"Print sizes as exact byte counts":                             "Größen als genaue Byteanzahl anzeigen",
+		"Print sizes in units of this size (e.g. 4K, 1M)":              "Größen in Einheiten dieser Größe anzeigen (z.B. 4K, 1M)",
+		"\n  [ERROR] --bytes and -B cannot be used together\n\n":       "\n  [FEHLER] --bytes und -B können nicht zusammen verwendet werden\n\n",
+		"\n  [ERROR] -B: invalid size: %q\n\n":                         "\n  [FEHLER] -B: ungültige Größe: %q\n\n",
+		"Print \"SIZE<tab>PATH\" lines in kilobytes like du -skx":      "\"GRÖSSE<tab>PFAD\"-Zeilen in Kilobytes wie du -skx ausgeben",
+		"\n  [ERROR] --du cannot be used with --jsonl or --format\n\n": "\n  [FEHLER] --du kann nicht mit --jsonl oder --format verwendet werden\n\n",
+		"Show a bar of each item's usage in the table":                 "Einen Balken der Belegung jedes Eintrags in der Tabelle anzeigen",
+		" items, %s/s":         " Elemente, %s/s",
+		", ~%d%% done, ETA %s": ", ~%d%% erledigt, noch %s",
+		"Quiet: only print the results, without title, progress nor partition info":   "Still: nur die Ergebnisse ausgeben, ohne Titel, Fortschritt und Partition",
+		"  Interrupted, showing the items scanned so far":                             "  Unterbrochen, bisher durchsuchte Elemente werden angezeigt",
+		"Interrupted: partial results":                                                "Unterbrochen: Teilergebnisse",
+		"Show the content of a zip or tar archive instead of scanning":                "Inhalt eines zip- oder tar-Archivs anzeigen statt zu analysieren",
+		"  [ERROR] Cannot read archive: %v\n":                                         "  [FEHLER] Archiv nicht lesbar: %v\n",
+		"  Content of archive %s\n\n":                                                 "  Inhalt des Archivs %s\n\n",
+		"Show exclusive and shared extents of each item (Linux only)":                 "Exklusive und geteilte Extents jedes Elements anzeigen (nur Linux)",
+		"           Total|   Exclusive|      Shared|\n":                               "          Gesamt|    Exklusiv|     Geteilt|\n",
+		"  =%13s|%12s|%12s| in all files\n":                                           "  =%13s|%12s|%12s| in allen Dateien\n",
+		"Show the compression ratio of each item (disk usage against apparent size)":  "Kompressionsrate jedes Elements anzeigen (Belegung gegenüber scheinbarer Größe)",
+		"      Disk usage|    Apparent| Ratio|\n":                                     "        Belegung|   Scheinbar|  Rate|\n",
+		"  =%13s|%12s|%6s| in total\n":                                                "  =%13s|%12s|%6s| insgesamt\n",
+		"  Quota   :%10s used (%2d%%) of %10s. Avail:%10s (user %s)\n":                "  Quota   :%10s belegt (%2d%%) von %10s. Frei:%10s (Benutzer %s)\n",
+		"  Quota   :%10s used (%2d%%) of %10s. Avail:%10s (group %s)\n":               "  Quota   :%10s belegt (%2d%%) von %10s. Frei:%10s (Gruppe %s)\n",
+		"List the mounted filesystems with their usage, like df":                      "Eingehängte Dateisysteme mit ihrer Belegung auflisten, wie df",
+		"\n  [ERROR] --mounts takes no directory, export or manifest file\n\n":        "\n  [FEHLER] --mounts akzeptiert weder Verzeichnis noch Export- oder Manifestdatei\n\n",
+		"          Size|        Used|       Avail| Use%|IUse%| Type     Mountpoint\n": "         Größe|      Belegt|        Frei| Bel%|IBel%| Typ      Einhängepunkt\n",
+		"Same as --all-mounts":                                                        "Wie --all-mounts",
+		"Same as --tag-other-fs":                                                      "Wie --tag-other-fs",
+		"Also cross into pseudo filesystems (proc, sysfs, tmpfs...)":                  "Auch Pseudo-Dateisysteme durchlaufen (proc, sysfs, tmpfs...)",
+		"  Skipping pseudo filesystem at %-15s %s":                                    "  Pseudo-Dateisystem übersprungen bei %-15s %s",
+		"bind mount of %s":       "Bind-Mount von %s",
+		"overlay of %s":          "Overlay von %s",
+		"  Not scanning %s (%s)": "  %s nicht analysiert (%s)",
+		"  Not counted twice: %d bind or overlay mounts\n":                    "  Nicht doppelt gezählt: %d Bind- oder Overlay-Mounts\n",
+		"Show the usage of each Docker or Podman image, container and volume": "Belegung jedes Docker- oder Podman-Images, -Containers und -Volumes anzeigen",
+		"(layers shared by several images)":                                   "(von mehreren Images geteilte Schichten)",
+		"(unused layers, build cache)":                                        "(unbenutzte Schichten, Build-Cache)",
+		"image":                                                               "Image",
+		"container":                                                           "Container",
+		"volume":                                                              "Volume",
+		"other":                                                               "Sonstiges",
+		"  =%13s| in %s\n":                                                    "  =%13s| in %s\n",
+		"Show the usage of the systemd journal, the largest rotated logs and the growing ones": "Belegung des systemd-Journals, die größten rotierten Logs und die wachsenden anzeigen",
+		"  Logs    :%12s in %s\n":       "  Logs    :%12s in %s\n",
+		"  Journal :%12s in %d files\n": "  Journal :%12s in %d Dateien\n",
+		"Show the space that cleaning package caches, user caches, trash and old kernels would free": "Den Platz anzeigen, den das Leeren der Paket-Caches, Benutzer-Caches, des Papierkorbs und alter Kernel freigeben würde",
+		"remove the kernel package":                                                                 "das Kernel-Paket entfernen",
+		"  =%13s| could be freed, nothing was deleted\n":                                            "  =%13s| könnten freigegeben werden, nichts wurde gelöscht\n",
+		"Show the usage of directories tagged with CACHEDIR.TAG apart":                              "Die Belegung der mit CACHEDIR.TAG markierten Verzeichnisse getrennt anzeigen",
+		"Skip directories tagged with CACHEDIR.TAG":                                                 "Mit CACHEDIR.TAG markierte Verzeichnisse überspringen",
+		"  Not scanning %s (cache directory)":                                                       "  %s übersprungen (Cache-Verzeichnis)",
+		"  =%13s| in %d directories tagged with CACHEDIR.TAG\n":                                     "  =%13s| in %d mit CACHEDIR.TAG markierten Verzeichnissen\n",
+		"  Use --exclude-caches-all to skip them.":                                                  "  Mit --exclude-caches-all werden sie übersprungen.",
+		"Show the usage of items ignored by git apart, in git repositories":                         "Die Belegung der von git ignorierten Elemente in git-Repositorys getrennt anzeigen",
+		"Skip items ignored by git, in git repositories":                                            "Von git ignorierte Elemente in git-Repositorys überspringen",
+		"  =%13s| ignored in %d git repositories\n":                                                 "  =%13s| ignoriert in %d git-Repositorys\n",
+		"  Use --exclude-gitignored to skip them.":                                                  "  Mit --exclude-gitignored werden sie übersprungen.",
+		"Show the usage of each git repository, its history against its working tree":               "Die Belegung jedes git-Repositorys anzeigen, seine Historie gegenüber dem Arbeitsverzeichnis",
+		"            .git|       Packs|       Loose|Working tree|\n":                                "            .git|       Packs|       Lose |Arbeitsverz.|\n",
+		"  * %d repositories whose .git is larger than the working tree\n":                          "  * %d Repositorys, deren .git größer als das Arbeitsverzeichnis ist\n",
+		"Show apart the usage of the build directories of a preset: dev":                            "Die Belegung der Build-Verzeichnisse einer Vorgabe getrennt anzeigen: dev",
+		"Skip the directories of the --prune-preset":                                                "Die Verzeichnisse von --prune-preset überspringen",
+		"\n  [ERROR] Unknown prune preset %q\n\n":                                                   "\n  [FEHLER] Unbekannte Bereinigungsvorgabe %q\n\n",
+		"  =%13s| of build artifacts under these %d directories\n":                                  "  =%13s| an Build-Artefakten in diesen %d Verzeichnissen\n",
+		"  Use --prune to skip them.":                                                               "  Mit --prune werden sie übersprungen.",
+		"Show the inodes used by each item, against the inode table of the filesystem":              "Die von jedem Element belegten Inodes anzeigen, gemessen an der Inode-Tabelle des Dateisystems",
+		"          Inodes| % of fs|\n":                                                              "          Inodes|% des DS|\n",
+		"  =%13d|%8s| in total, of %d inodes\n":                                                     "  =%13d|%8s| insgesamt, von %d Inodes\n",
+		"List world-writable, setuid and setgid files, and files of deleted users (not on Windows)": "Für alle beschreibbare, setuid- und setgid-Dateien sowie Dateien gelöschter Benutzer auflisten (nicht unter Windows)",
+		"%3d. uid %-8d|%12s|%9d files\n":                                                            "%3d. UID %-8d|%12s|%9d Dateien\n",
+		"Show disk usage per category of files: videos, images, archives...":                        "Belegung pro Dateikategorie anzeigen: Videos, Bilder, Archive...",
+		"videos":    "Videos",
+		"images":    "Bilder",
+		"audio":     "Audio",
+		"archives":  "Archive",
+		"vm images": "VM-Images",
+		"documents": "Dokumente",
+		"code":      "Code",
+		"Show the space lost to the rounding of files to whole blocks":              "Den durch das Aufrunden von Dateien auf ganze Blöcke verlorenen Platz anzeigen",
+		"           Slack| % of du|     Files|\n":                                   "         Verlust|% Beleg.|   Dateien|\n",
+		"  =%13s|%7.2f%%| of the total disk usage\n":                                "  =%13s|%7.2f%%| der gesamten Belegung\n",
+		"Number of zero-byte files and directories of tiny files shown (default 0)": "Anzahl der angezeigten leeren Dateien und Verzeichnisse mit winzigen Dateien (Standard 0)",
+		"  %d zero-byte files in total\n":                                           "  %d leere Dateien insgesamt\n",
+		"%3d.%9d of %9d files under 4 KiB| %s\n":                                    "%3d.%9d von %9d Dateien < 4 KiB  | %s\n",
+		"Write the size tree as a Graphviz DOT graph to a file":                     "Den Größenbaum als Graphviz-DOT-Graph in eine Datei schreiben",
+		"  [ERROR] Cannot write graph: %v\n":                                        "  [FEHLER] Graph kann nicht geschrieben werden: %v\n",
+		"Write the size tree as folded stacks for flame graphs to a file":           "Den Größenbaum als gefaltete Stacks für Flame Graphs in eine Datei schreiben",
+		"Print the usage of each item in the InfluxDB line protocol":                "Die Belegung jedes Elements im Line-Protokoll von InfluxDB ausgeben",
+		"Print the usage of each item in the Graphite plaintext protocol":           "Die Belegung jedes Elements im Klartextprotokoll von Graphite ausgeben",
+		"        %s history [options] [DIR]\n":                                      "        %s history [Optionen] [VERZ]\n",
+		"Append a summary of the scan to the history shown by 'tdu history'":        "Eine Zusammenfassung des Scans an den von 'tdu history' gezeigten Verlauf anhängen",
+		"Number of runs shown by 'tdu history'":                                     "Anzahl der von 'tdu history' gezeigten Läufe",
+		"\n  [ERROR] history needs at most one directory\n\n":                       "\n  [FEHLER] history erwartet höchstens ein Verzeichnis\n\n",
+		"  [ERROR] Cannot write history: %v\n":                                      "  [FEHLER] Verlauf kann nicht geschrieben werden: %v\n",
+		"  [ERROR] Cannot read history: %v\n":                                       "  [FEHLER] Verlauf kann nicht gelesen werden: %v\n",
+		"  No history of %s yet: scan it with --history.\n":                         "  Noch kein Verlauf für %s: mit --history scannen.\n",
+		"Previous run": "Letzter Lauf",
+		"Last %d runs": "Letzte %d Läufe",
+		"Write progress events as JSON Lines to stderr or to a file descriptor number": "Fortschritt als JSON Lines auf stderr oder einen Dateideskriptor schreiben",
+		"not stderr nor a file descriptor: %q":                                         "weder stderr noch ein Dateideskriptor: %q",
+		"\n  [ERROR] Cannot write progress events: %v\n\n":                             "\n  [FEHLER] Fortschritt kann nicht geschrieben werden: %v\n\n",
+		"Print the results with a Go template file (text/template)":                    "Ergebnisse mit einer Go-Vorlage ausgeben (text/template)",
+		"\n  [ERROR] --template cannot be used with --jsonl or --format\n\n":           "\n  [FEHLER] --template kann nicht mit --jsonl oder --format verwendet werden\n\n",
+		"\n  [ERROR] Cannot read template: %v\n\n":                                     "\n  [FEHLER] Vorlage kann nicht gelesen werden: %v\n\n",
+		"Print the main table, the biggest files and a summary as Markdown tables":     "Haupttabelle, größte Dateien und Zusammenfassung als Markdown-Tabellen ausgeben",
+		"Item":          "Eintrag",
+		"Items":         "Einträge",
+		"Biggest files": "Größte Dateien",
+		"File":          "Datei",
+		"Summary":       "Zusammenfassung",
+		"Disk usage":    "Belegter Platz",
+		"Apparent size": "Scheinbare Größe",
+		"Directories":   "Verzeichnisse",
+		"Files":         "Dateien",
+		"Denied":        "Verweigert",
+		"Errors":        "Fehler",
+		"Scan time":     "Scandauer",
+		"\n  [STATUS] %d items, %s, %d errors, %d denied, depth %d, %.1f s\n  in %s\n":      "\n  [STATUS] %d Einträge, %s, %d Fehler, %d verweigert, Tiefe %d, %.1f s\n  in %s\n",
+		"Stop scanning after this duration (e.g. 90s, 10m)":                                 "Suche nach dieser Dauer beenden (z. B. 90s, 10m)",
+		"  Scan timeout reached, not descending further":                                    "  Zeitlimit erreicht, Suche wird beendet",
+		"Skip a path that does not answer in this time, like a dead NFS mount (0: wait)":    "Pfad ohne Antwort nach dieser Zeit überspringen, z. B. ein totes NFS-Mount (0: warten)",
+		"  Skipped after %v without response (--stat-timeout)\n":                            "  Nach %v ohne Antwort übersprungen (--stat-timeout)\n",
+		"Only scan this fraction of the subdirectories (e.g. 0.1) and extrapolate":          "Nur diesen Anteil der Unterverzeichnisse durchsuchen (z. B. 0.1) und hochrechnen",
+		"\n  [ERROR] --estimate takes a fraction between 0 and 1\n\n":                       "\n  [FEHLER] --estimate erwartet einen Anteil zwischen 0 und 1\n\n",
+		"  =%13s| ±%10s| at 95%% confidence\n":                                              "  =%13s| ±%10s| bei 95%% Konfidenz\n",
+		"  %d of %d subdirectories scanned (%.1f%%), the other lists only cover them.\n":    "  %d von %d Unterverzeichnissen durchsucht (%.1f%%), die anderen Listen decken nur diese ab.\n",
+		"Same output for the same tree: ties sorted by path, no times, width of 80 columns": "Gleiche Ausgabe für denselben Baum: Gleichstände nach Pfad sortiert, ohne Zeiten, 80 Spalten breit",
+		"List the fixed drives with their size and free space (Windows)":                    "Listet die festen Laufwerke mit Größe und freiem Speicher auf (Windows)",
+		"\n  [ERROR] --drives only works on Windows, see --mounts\n\n":                      "\n  [FEHLER] --drives funktioniert nur unter Windows, siehe --mounts\n\n",
+		"  [ERROR] No fixed drive found":                                                    "  [FEHLER] Kein festes Laufwerk gefunden",
+		"          Size|        Used|        Free| Use%| Type     Drive\n":                  "         Größe|      Belegt|        Frei| Bel%| Typ      Laufwerk\n",
+		"  Scan them all with --all-mounts, or some with: tdu C:\\ D:\\":                    "  Alle mit --all-mounts scannen, oder einige mit: tdu C:\\ D:\\",
+		"  [ERROR] Cannot scan [%s]: %v\n":                                                  "  [FEHLER] [%s] kann nicht analysiert werden: %v\n",
+		"\n  [ERROR] --enable-delete cannot be used with --plain\n\n":                       "\n  [FEHLER] --enable-delete kann nicht mit --plain verwendet werden\n\n",
+	},
+}
+
+func tr(msg string) string {
+	if t, ok := catalog[msg]; ok {
+		return t
+	}
+	return msg
+}
+
+/* Section title padded to the width of the English ones */
+func header(title string) string {
+	t := tr(title)
+	n := 26 - utf8.RuneCountInString(t)
+	if n < 3 {
+		n = 3
+	}
+	return "  --------- " + t + " " + strings.Repeat("-", n)
+}
+
+/* The language is selected before parsing the command line, so that
+ * help messages are translated too: --lang, then LC_ALL, LC_MESSAGES
+ * and LANG. */
+func initLang() {
+	var lang string
+	for i, a := range os.Args[1:] {
+		a = strings.TrimLeft(a, "-")
+		if strings.HasPrefix(a, "lang=") {
+			lang = a[5:]
+		} else if a == "lang" && i+2 < len(os.Args) {
+			lang = os.Args[i+2]
+		}
+	}
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang != "" {
+			break
+		}
+		lang = os.Getenv(v)
+	}
+	if len(lang) >= 2 {
+		catalog = catalogs[strings.ToLower(lang[:2])]
+	}
+}
@@ -0,0 +1,282 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Reader for the Ncdu JSON format (https://dev.yorhel.nl/ncdu/jsonfmt).
+ * A directory is an array whose first element describes the directory
+ * itself, followed by its entries.  A file is a single object.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+type node struct { // Item read from an Ncdu export
+	Name      string  `json:"name"`
+	Size      int64   `json:"asize"`
+	DiskUsage int64   `json:"dsize"`
+	Device    uint64  `json:"dev"`
+	Inode     uint64  `json:"ino"`
+	Hardlink  bool    `json:"hlnkc"`
+	NotReg    bool    `json:"notreg"`
+	ReadError bool    `json:"read_error"`
+	Excluded  string  `json:"excluded"`
+	IsDir     bool    `json:"-"`
+	Symlink   bool    `json:"-"` // only known in tdu snapshots
+	Children  []*node `json:"-"`
+}
+
+/* Names keep their bytes that are not valid UTF-8, see ncdu_name */
+func (n *node) UnmarshalJSON(b []byte) error {
+	type fields node // without this method
+	v := struct {
+		*fields
+		Name ncdu_name `json:"name"`
+	}{fields: (*fields)(n)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	n.Name = string(v.Name)
+	return nil
+}
+
+/* The device id is only written when it differs from the parent one */
+func parseNcduItem(raw json.RawMessage, dev uint64) (*node, error) {
+	n := node{Device: dev}
+	if len(raw) > 0 && raw[0] == '{' {
+		err := json.Unmarshal(raw, &n)
+		return &n, err
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("empty directory array")
+	}
+	if err := json.Unmarshal(items[0], &n); err != nil {
+		return nil, err
+	}
+	n.IsDir = true
+	for _, it := range items[1:] {
+		c, err := parseNcduItem(it, n.Device)
+		if err != nil {
+			return nil, err
+		}
+		n.Children = append(n.Children, c)
+	}
+	return &n, nil
+}
+
+func readNcdu(path string) (*node, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var top []json.RawMessage
+	if err := json.Unmarshal(b, &top); err != nil {
+		return nil, err
+	}
+	if len(top) < 4 {
+		return nil, fmt.Errorf("%s: not an Ncdu export", path)
+	}
+	var major int
+	if err := json.Unmarshal(top[0], &major); err != nil || major != 1 {
+		return nil, fmt.Errorf("%s: unsupported Ncdu format version", path)
+	}
+	return parseNcduItem(top[3], 0)
+}
+
+/* Total disk usage (or apparent size) of a node, hardlinks being
+ * counted only once */
+func (n *node) total(seen ino_map, apparent bool) int64 {
+	du := n.DiskUsage
+	if apparent {
+		du = n.Size
+	}
+	if n.Hardlink {
+		key := ino_key{n.Device, n.Inode}
+		if seen[key] > 0 {
+			du = 0
+		}
+		seen[key]++
+	}
+	for _, c := range n.Children {
+		du += c.total(seen, apparent)
+	}
+	return du
+}
+
+/* Disk usage of each depth 1 item of a previous export */
+func loadBaseline(sc *s_scan, path string) error {
+	root, err := readNcdu(path)
+	if err != nil {
+		return err
+	}
+	seen := make(ino_map)
+	sc.baseline = make(map[string]int64, len(root.Children))
+	for _, c := range root.Children {
+		sc.baseline[c.Name] = c.total(seen, sc.apparent)
+	}
+	return nil
+}
+
+func baselineDelta(sc *s_scan, f *file) string {
+	if sc.baseline == nil {
+		return ""
+	}
+	old, ok := sc.baseline[f.name]
+	if !ok {
+		return " (new)"
+	}
+	d := shownSize(sc, f.diskUsage, f.size) - old
+	switch {
+	case d > 0:
+		return fmt.Sprintf(" %s +%s", arrow_UP, fmtSz(sc, d))
+	case d < 0:
+		return fmt.Sprintf(" %s -%s", arrow_DOWN, fmtSz(sc, -d))
+	}
+	return ""
+}
+
+/* Shows the results of an Ncdu export, or of tdu -o */
+func importNcdu(sc *s_scan) {
+	root, err := readNcdu(sc.importPath)
+	if err != nil {
+		fmt.Fprintf(sc.out, tr("  [ERROR] Cannot import: %v\n"), err)
+		return
+	}
+	if !sc.quiet {
+		fmt.Fprintf(sc.out, tr("  Imported [%s] from %s\n\n"), root.Name, sc.importPath)
+	}
+	fi, t := replay(sc, root)
+	showResults(sc, fi, t)
+}
+
+/* Rebuilds the results of a scan from a tree read from a file, as if the
+ * directory had just been scanned (see scan). */
+func replay(sc *s_scan, root *node) ([]file, *file) {
+	sc.root = root.Name
+	var fi []file
+	t := replayNode(sc, root, ".", 1, make(ino_map), &fi)
+	return fi, t
+}
+
+func replayNode(sc *s_scan, n *node, path string, depth int64,
+	seen ino_map, files *[]file) *file {
+	if n.Excluded != "" && n.Excluded != "othfs" {
+		sc.nExcluded++
+		return nil
+	}
+	sc.nItems++
+	f := file{path: path, fullpath: fullPath(sc, path), name: n.Name,
+		depth: depth, size: n.Size, diskUsage: n.DiskUsage, inode: n.Inode,
+		deviceId: n.Device, isDir: n.IsDir, isSymlink: n.Symlink,
+		readError: n.ReadError}
+	if l := int64(len(f.fullpath)); f.isDir && l > sc.maxPathLen {
+		sc.maxPathLen = l
+		sc.longestPath = f.fullpath
+	}
+	if l := int64(len(f.name)); l > sc.maxFNameLen {
+		sc.maxFNameLen = l
+		sc.longestFName = f.name
+	}
+	if depth > sc.reachedDepth {
+		sc.reachedDepth = depth
+		sc.deepestPath = filepath.Dir(f.fullpath)
+	}
+	if n.Hardlink {
+		key := ino_key{n.Device, n.Inode}
+		if seen[key] > 0 {
+			f.diskUsage = 0
+			sc.nHardlinks++
+		}
+		seen[key]++
+	}
+	switch {
+	case f.isDir:
+		sc.nDirs++
+	case f.isSymlink:
+		sc.nSymlinks++
+	case n.NotReg:
+		f.isSpecial = true
+	default:
+		f.isRegular = true
+		sc.nFiles++
+		countExt(sc, &f)
+	}
+	if n.Excluded == "othfs" {
+		f.isOtherFs = true
+		sc.foundBoundary = true
+		sc.otherfs = append(sc.otherfs, f)
+		return &f
+	}
+	if !f.isDir {
+		if files != nil {
+			*files = append(*files, f)
+		}
+		if len(sc.bigfiles) > sc.maxBigFiles*4 {
+			sortFiles(sc, sc.bigfiles)
+			sc.bigfiles = sc.bigfiles[0:sc.maxBigFiles]
+		}
+		sc.bigfiles = append(sc.bigfiles, f)
+		return &f
+	}
+	if f.readError {
+		sc.nDenied++
+		if len(sc.denieddirs) < sc.maxDenied {
+			sc.denieddirs = append(sc.denieddirs, f.path)
+		}
+	}
+	if len(n.Children) == 0 {
+		sc.nEmptyDir++
+		if len(sc.emptydirs) < sc.maxEmptyDirs {
+			sc.emptydirs = append(sc.emptydirs, f.path)
+		}
+	}
+	fo := f
+	var kids *[]file
+	if depth > 1 && depth <= sc.maxDepth {
+		kids = new([]file)
+	}
+	for _, c := range n.Children {
+		ptr := files
+		if depth > 1 {
+			ptr = kids
+		}
+		subpath := c.Name
+		if path != "." {
+			subpath = path + sc.pathSeparator + c.Name
+		}
+		cf := replayNode(sc, c, subpath, depth+1, seen, ptr)
+		if cf == nil {
+			continue
+		}
+		fo.size += cf.size
+		fo.diskUsage += cf.diskUsage
+		fo.items += cf.items + 1
+	}
+	countEntries(sc, path, int64(len(n.Children)), fo.items)
+	if kids != nil {
+		sortFiles(sc, *kids)
+		if len(*kids) > dft_DEPTHITEMS {
+			*kids = (*kids)[:dft_DEPTHITEMS]
+		}
+		fo.kids = *kids
+	}
+	if depth > 1 && files != nil {
+		*files = append(*files, fo)
+	}
+	return &fo
+}
@@ -0,0 +1,64 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Inodes used by each depth 1 item (--inodes), against the inode table
+ * of the filesystem: a filesystem may be full of small files long before
+ * its blocks are all used. */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+func inodes(f *file) int64 {
+	if f.isDir {
+		return f.items + 1
+	}
+	return 1
+}
+
+func inodePercent(sc *s_scan, n int64) string {
+	if sc.inodeTotal == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", float64(n)*100/float64(sc.inodeTotal))
+}
+
+func showinodes(sc *s_scan, fi []file, total *file) {
+	if !sc.inodeReport || len(fi) == 0 {
+		return
+	}
+	list := make([]file, len(fi))
+	copy(list, fi)
+	sort.Slice(list, func(i, j int) bool { return inodes(&list[i]) > inodes(&list[j]) })
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("INODES"))
+	if !sc.plain {
+		fmt.Fprint(sc.out, tr("          Inodes| % of fs|\n"))
+	}
+	for i, f := range list {
+		if i >= sc.maxShownLines {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\n", inodes(&f), fullPath(sc, f.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12d|%8s| %s\n", i+1, inodes(&f),
+			inodePercent(sc, inodes(&f)), smartTruncate(f.path, sc.maxNameLen+8))
+	}
+	if sc.plain {
+		return
+	}
+	fmt.Fprintf(sc.out, tr("  =%13d|%8s| in total, of %d inodes\n"), inodes(total),
+		inodePercent(sc, inodes(total)), sc.inodeTotal)
+}
@@ -13,9 +13,316 @@
 package main
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
 	"syscall"
+	"unsafe"
 )
 
 func tcgets() uintptr {
 	return uintptr(syscall.TCGETS)
 }
+
+func statFs(path string, st *fs_stat) error {
+	var s syscall.Statfs_t
+	if err := syscall.Statfs(path, &s); err != nil {
+		return err
+	}
+	*st = fs_stat{int64(s.Type), int64(s.Flags), uint64(s.Bsize), s.Blocks,
+		s.Bfree, s.Bavail, s.Files, s.Ffree}
+	return nil
+}
+
+// Mounts are read from /proc/mounts on Linux
+func fsMounts() []mount_entry {
+	return nil
+}
+
+// Same as syscall.Listxattr, without following a symbolic link
+func llistxattr(path string, dest []byte) (int, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var d unsafe.Pointer
+	if len(dest) > 0 {
+		d = unsafe.Pointer(&dest[0])
+	}
+	r, _, e := syscall.Syscall(syscall.SYS_LLISTXATTR, uintptr(unsafe.Pointer(p)),
+		uintptr(d), uintptr(len(dest)))
+	if e != 0 {
+		return 0, e
+	}
+	return int(r), nil
+}
+
+// Same as syscall.Getxattr, without following a symbolic link
+func lgetxattr(path, name string, dest []byte) (int, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	a, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+	var d unsafe.Pointer
+	if len(dest) > 0 {
+		d = unsafe.Pointer(&dest[0])
+	}
+	r, _, e := syscall.Syscall6(syscall.SYS_LGETXATTR, uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(a)), uintptr(d), uintptr(len(dest)), 0, 0)
+	if e != 0 {
+		return 0, e
+	}
+	return int(r), nil
+}
+
+/* Total size of the names and values of all extended attributes, those of
+ * a symbolic link and not of its target */
+func xattrSize(path string) int64 {
+	n, err := llistxattr(path, nil)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	buf := make([]byte, n)
+	n, err = llistxattr(path, buf)
+	if err != nil {
+		return 0
+	}
+	size := int64(n)
+	for _, name := range strings.Split(string(buf[:n]), "\x00") {
+		if name == "" {
+			continue
+		}
+		if v, err := lgetxattr(path, name, nil); err == nil {
+			size += int64(v)
+		}
+	}
+	return size
+}
+
+/* Value of an extended attribute, if set */
+func getXattr(path, name string) (string, bool) {
+	buf := make([]byte, 256)
+	n, err := lgetxattr(path, name, buf)
+	if err != nil || n > len(buf) {
+		return "", false
+	}
+	return string(buf[:n]), true
+}
+
+var statusSignals = []os.Signal{syscall.SIGUSR1}
+
+const (
+	fs_IOC_FIEMAP        = 0xC020660B // _IOWR('f', 11, struct fiemap)
+	fiemap_EXTENT_LAST   = 0x1
+	fiemap_EXTENT_SHARED = 0x2000
+	fiemap_BATCH         = 64 // extents read by each ioctl
+)
+
+type fiemap_extent struct {
+	logical  uint64
+	physical uint64
+	length   uint64
+	_        [2]uint64
+	flags    uint32
+	_        [3]uint32
+}
+
+type fiemap struct {
+	start         uint64
+	length        uint64
+	flags         uint32
+	mappedExtents uint32
+	extentCount   uint32
+	_             uint32
+	extents       [fiemap_BATCH]fiemap_extent
+}
+
+/* Bytes of the extents of a file, and of those shared with other files */
+func fileExtents(path string) (int64, int64, bool) {
+	fd, err := syscall.Open(path, syscall.O_RDONLY|syscall.O_NOFOLLOW|
+		syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return 0, 0, false
+	}
+	defer syscall.Close(fd)
+	var total, shared int64
+	var m fiemap
+	for {
+		m.length = ^uint64(0) - m.start
+		m.extentCount = fiemap_BATCH
+		_, _, e := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), fs_IOC_FIEMAP,
+			uintptr(unsafe.Pointer(&m)))
+		if e != 0 { // not supported by this filesystem
+			return 0, 0, false
+		}
+		if m.mappedExtents == 0 {
+			return total, shared, true
+		}
+		for _, x := range m.extents[:m.mappedExtents] {
+			total += int64(x.length)
+			if x.flags&fiemap_EXTENT_SHARED != 0 {
+				shared += int64(x.length)
+			}
+			if x.flags&fiemap_EXTENT_LAST != 0 {
+				return total, shared, true
+			}
+		}
+		last := m.extents[m.mappedExtents-1]
+		m.start = last.logical + last.length
+	}
+}
+
+const (
+	q_GETQUOTA  = 0x800007
+	usr_QUOTA   = 0
+	grp_QUOTA   = 1
+	qif_BLIMITS = 1
+	qif_BLOCK   = 1024 // unit of the block limits
+)
+
+type if_dqblk struct { // struct if_dqblk of quotactl(2)
+	bHardLimit uint64
+	bSoftLimit uint64
+	curSpace   uint64 // bytes
+	iHardLimit uint64
+	iSoftLimit uint64
+	curInodes  uint64
+	bTime      uint64
+	iTime      uint64
+	valid      uint32
+}
+
+func quotactl(cmd uint32, special string, id int, q *if_dqblk) error {
+	p, err := syscall.BytePtrFromString(special)
+	if err != nil {
+		return err
+	}
+	_, _, e := syscall.Syscall6(syscall.SYS_QUOTACTL, uintptr(cmd),
+		uintptr(unsafe.Pointer(p)), uintptr(id), uintptr(unsafe.Pointer(q)), 0, 0)
+	if e != 0 {
+		return e
+	}
+	return nil
+}
+
+/* Block quotas of the current user and group on a device, if any */
+func getQuotas(device string) []quota_usage {
+	if !strings.HasPrefix(device, "/dev/") {
+		return nil
+	}
+	var r []quota_usage
+	for _, t := range []int{usr_QUOTA, grp_QUOTA} {
+		id, name := os.Getuid(), userName
+		if t == grp_QUOTA {
+			id, name = os.Getgid(), groupName
+		}
+		var q if_dqblk
+		cmd := uint32(q_GETQUOTA)<<8 | uint32(t) // QCMD(Q_GETQUOTA, t)
+		if quotactl(cmd, device, id, &q) != nil || q.valid&qif_BLIMITS == 0 {
+			continue // no quota on this filesystem, or none for this id
+		}
+		limit := q.bSoftLimit
+		if limit == 0 || (q.bHardLimit > 0 && q.bHardLimit < limit) {
+			limit = q.bHardLimit
+		}
+		if limit == 0 {
+			continue
+		}
+		r = append(r, quota_usage{name: name(uint32(id)), group: t == grp_QUOTA,
+			used: int64(q.curSpace), limit: int64(limit * qif_BLOCK)})
+	}
+	return r
+}
+
+type mount_info struct { // Line of /proc/self/mountinfo
+	dev     string // major:minor
+	root    string // directory of the filesystem shown at the mountpoint
+	dir     string // mountpoint
+	fsType  string
+	options string // superblock options, with the directories of overlays
+}
+
+var mountUnescape = strings.NewReplacer("\\040", " ", "\\011", "\t", "\\012", "\n", "\\134", "\\")
+
+func readMountInfo() []mount_info {
+	b, err := ioutil.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return nil
+	}
+	var ms []mount_info
+	for _, line := range strings.Split(string(b), "\n") {
+		// id parent major:minor root mountpoint options [tags] - fstype source superoptions
+		parts := strings.SplitN(line, " - ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		f, g := strings.Fields(parts[0]), strings.Fields(parts[1])
+		if len(f) < 5 || len(g) < 3 {
+			continue
+		}
+		ms = append(ms, mount_info{dev: f[2], root: mountUnescape.Replace(f[3]),
+			dir: mountUnescape.Replace(f[4]), fsType: g[0], options: g[2]})
+	}
+	return ms
+}
+
+func pathUnder(p, dir string) bool {
+	return p == dir || dir == "/" || strings.HasPrefix(p, dir+"/")
+}
+
+/* Mountpoints under root showing what the scan already counts elsewhere:
+ * bind mounts of a directory of a scanned filesystem, and overlays made
+ * of directories under root. The reason is given for each of them. */
+func dupMounts(root string, crossFs bool) map[string]string {
+	ms := readMountInfo()
+	var top *mount_info // the filesystem holding root, the last one mounted
+	for i := range ms {
+		if pathUnder(root, ms[i].dir) {
+			top = &ms[i]
+		}
+	}
+	if top == nil {
+		return nil
+	}
+	type view struct{ dev, root, dir string }
+	views := []view{{top.dev, path.Join(top.root, strings.TrimPrefix(root, top.dir)), root}}
+	dups := make(map[string]string)
+	for _, m := range ms {
+		if m.dir == root || !pathUnder(m.dir, root) || m == *top {
+			continue
+		}
+		if m.dev != top.dev && !crossFs {
+			continue // another filesystem, not scanned anyway
+		}
+		for _, v := range views {
+			if v.dev == m.dev && pathUnder(m.root, v.root) {
+				orig := path.Join(v.dir, strings.TrimPrefix(m.root, v.root))
+				dups[m.dir] = fmt.Sprintf(tr("bind mount of %s"), orig)
+				break
+			}
+		}
+		if m.fsType == "overlay" {
+			for _, o := range strings.Split(m.options, ",") {
+				kv := strings.SplitN(o, "=", 2)
+				if len(kv) != 2 || (kv[0] != "lowerdir" && kv[0] != "upperdir") {
+					continue
+				}
+				for _, d := range strings.Split(kv[1], ":") {
+					if pathUnder(d, root) {
+						dups[m.dir] = fmt.Sprintf(tr("overlay of %s"), d)
+					}
+				}
+			}
+		}
+		if _, ok := dups[m.dir]; !ok {
+			views = append(views, view{m.dev, m.root, m.dir})
+		}
+	}
+	return dups
+}
@@ -0,0 +1,144 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Logs are the most common disk fillers (--logs). When /var/log is in the
+ * scanned directory, the usage of the systemd journal is summed like
+ * 'journalctl --disk-usage' does, and the largest rotated logs and the
+ * logs still growing (written during the last day) are listed. */
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	cst_LOGDIR  = "/var/log"
+	dft_MAXLOGS = 5 // files shown in each list
+)
+
+type log_usage struct {
+	rel      string // log directory, relative to the scanned one
+	dir      string // log directory, or the scanned one when inside it
+	total    int64
+	journal  int64 // systemd journal files
+	nJournal int64
+	rotated  []file // largest rotated or compressed logs
+	growing  []file // largest logs written during the last day
+}
+
+/* Log directory, if it is or contains the scanned directory */
+func findLogs(root string) *log_usage {
+	rel, err := filepath.Rel(root, cst_LOGDIR)
+	if err != nil {
+		return nil
+	}
+	if strings.HasPrefix(rel, "..") {
+		if !strings.HasPrefix(root, cst_LOGDIR+"/") {
+			return nil
+		}
+		rel = "."
+	}
+	if rel == "." {
+		rel = ""
+	}
+	return &log_usage{rel: rel, dir: filepath.Join(root, rel)}
+}
+
+/* syslog.1, messages-20210301, dpkg.log.2.gz, Xorg.0.log.old */
+func isRotated(name string) bool {
+	for _, ext := range []string{".gz", ".xz", ".bz2", ".zst", ".old"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	digits := func(s string) bool {
+		for _, c := range s {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+		return s != ""
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 && digits(name[i+1:]) {
+		return true
+	}
+	i := strings.LastIndex(name, "-")
+	return i >= 0 && len(name)-i == 9 && digits(name[i+1:])
+}
+
+func addLog(sc *s_scan, l []file, f *file) []file {
+	if len(l) > dft_MAXLOGS*4 {
+		sortFiles(sc, l)
+		l = l[:dft_MAXLOGS]
+	}
+	return append(l, *f)
+}
+
+func countLogs(sc *s_scan, f *file) {
+	lg := sc.logs
+	if lg == nil || !f.isRegular {
+		return
+	}
+	if lg.rel != "" && !strings.HasPrefix(f.path, lg.rel+sc.pathSeparator) {
+		return
+	}
+	lg.total += f.diskUsage
+	switch {
+	case strings.HasSuffix(f.name, ".journal") || strings.HasSuffix(f.name, ".journal~"):
+		lg.journal += f.diskUsage
+		lg.nJournal++
+	case isRotated(f.name):
+		lg.rotated = addLog(sc, lg.rotated, f)
+	case sc.start.Sub(f.mtime) < 24*time.Hour:
+		lg.growing = addLog(sc, lg.growing, f)
+	}
+}
+
+func showLogList(sc *s_scan, title string, l []file) {
+	if len(l) == 0 {
+		return
+	}
+	sortFiles(sc, l)
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header(title))
+	for i, f := range l {
+		if i >= dft_MAXLOGS {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\n", f.diskUsage, fullPath(sc, f.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s| %s\n", i+1, fmtSz(sc, f.diskUsage),
+			smartTruncate(f.path, sc.maxNameLen+18))
+	}
+}
+
+func showlogs(sc *s_scan) {
+	lg := sc.logs
+	if lg == nil || lg.total == 0 {
+		return
+	}
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("LOGS"))
+	if sc.plain {
+		fmt.Fprintf(sc.out, "%d\t%s\n", lg.total, lg.dir)
+		fmt.Fprintf(sc.out, "%d\t%d\tjournal\n", lg.journal, lg.nJournal)
+	} else {
+		fmt.Fprintf(sc.out, tr("  Logs    :%12s in %s\n"), fmtSz(sc, lg.total), lg.dir)
+		fmt.Fprintf(sc.out, tr("  Journal :%12s in %d files\n"), fmtSz(sc, lg.journal), lg.nJournal)
+	}
+	showLogList(sc, "LARGEST ROTATED LOGS", lg.rotated)
+	showLogList(sc, "GROWING LOGS (LAST 24H)", lg.growing)
+}
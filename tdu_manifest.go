@@ -0,0 +1,107 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Hash manifest of regular files (--manifest), one line per file:
+ * sha256 <TAB> size <TAB> mtime <TAB> path
+ * The hash is "-" for files above --manifest-max or unreadable. */
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+type manifest_entry struct {
+	path  string
+	size  int64
+	mtime time.Time
+	hash  string
+}
+
+func manifestAdd(sc *s_scan, f *file) {
+	if sc.manifestPath == "" || !f.isRegular {
+		return
+	}
+	e := manifest_entry{path: filepath.Clean(f.fullpath), size: f.size,
+		mtime: f.mtime, hash: "-"}
+	sc.manifest = append(sc.manifest, e)
+}
+
+func hashFile(path string) (string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, fd); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+/* Files are hashed by one worker per CPU */
+func hashManifest(sc *s_scan) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if h, err := hashFile(sc.manifest[i].path); err == nil {
+					sc.manifest[i].hash = h
+				}
+			}
+		}()
+	}
+	for i, e := range sc.manifest {
+		if sc.manifestMax > 0 && e.size > sc.manifestMax {
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func writeManifest(sc *s_scan) {
+	if sc.manifestPath == "" {
+		return
+	}
+	fd, err := os.Create(sc.manifestPath)
+	if err != nil {
+		fmt.Fprintf(sc.out, tr("\n  [ERROR] Cannot create manifest: %v\n"), err)
+		return
+	}
+	defer fd.Close()
+	hashManifest(sc)
+	sort.Slice(sc.manifest, func(i, j int) bool {
+		return sc.manifest[i].path < sc.manifest[j].path
+	})
+	w := bufio.NewWriter(fd)
+	for _, e := range sc.manifest {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", e.hash, e.size,
+			e.mtime.UTC().Format(time.RFC3339), e.path)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(sc.out, tr("\n  [ERROR] Cannot write manifest: %v\n"), err)
+	}
+}
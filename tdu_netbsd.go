@@ -0,0 +1,132 @@
+// +build netbsd
+
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func tcgets() uintptr {
+	return uintptr(syscall.TIOCGETA)
+}
+
+// Ctrl+T sends SIGINFO to the foreground process
+var statusSignals = []os.Signal{syscall.SIGUSR1, syscall.SIGINFO}
+
+const st_WAIT = 1 // statvfs1 asks the filesystem for fresh counts
+
+/* struct statvfs of <sys/statvfs.h>, which the syscall package does not
+ * provide: NetBSD has no statfs. An unsigned long is an uintptr. */
+type statvfs_t struct {
+	flag        uintptr
+	bsize       uintptr
+	frsize      uintptr // unit of the block counts
+	iosize      uintptr
+	blocks      uint64
+	bfree       uint64
+	bavail      uint64
+	bresvd      uint64
+	files       uint64
+	ffree       uint64
+	favail      uint64
+	fresvd      uint64
+	_           [4]uint64 // sync and async reads and writes
+	fsidx       [2]int32
+	fsid        uintptr
+	namemax     uintptr
+	owner       uint32
+	_           [4]uint32
+	fstypename  [32]int8
+	mntonname   [1024]int8
+	mntfromname [1024]int8
+}
+
+func statvfs(path string, st *statvfs_t) error {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return err
+	}
+	_, _, e := syscall.Syscall(syscall.SYS_STATVFS1, uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(st)), st_WAIT)
+	if e != 0 {
+		return e
+	}
+	return nil
+}
+
+func getvfsstat(buf []statvfs_t, flags int) (int, error) {
+	var p unsafe.Pointer
+	if len(buf) > 0 {
+		p = unsafe.Pointer(&buf[0])
+	}
+	n, _, e := syscall.Syscall(syscall.SYS_GETVFSSTAT, uintptr(p),
+		unsafe.Sizeof(statvfs_t{})*uintptr(len(buf)), uintptr(flags))
+	if e != 0 {
+		return 0, e
+	}
+	return int(n), nil
+}
+
+/* The filesystem type is a name, found by mountFsType in the mounts */
+func statFs(path string, st *fs_stat) error {
+	var s statvfs_t
+	if err := statvfs(path, &s); err != nil {
+		return err
+	}
+	*st = fs_stat{0, int64(s.flag), uint64(s.frsize), s.blocks, s.bfree, s.bavail,
+		s.files, s.ffree}
+	return nil
+}
+
+func fsMounts() []mount_entry {
+	return statMounts(getvfsstat, func(st *statvfs_t) mount_entry {
+		return mount_entry{device: cstr(st.mntfromname[:]), dir: cstr(st.mntonname[:]),
+			fsType: cstr(st.fstypename[:])}
+	})
+}
+
+// Extended attributes are not read on this OS
+func getXattr(path, name string) (string, bool) {
+	return "", false
+}
+
+func xattrSize(path string) int64 {
+	return 0
+}
+
+// Filesystem events are only watched on Linux, see usage()
+func newWatcher(sc *s_scan) scan_watcher { return nil }
+
+func watch(sc *s_scan, fi *[]file, t *file) {}
+
+// Entries of a directory opened in a Root are stat'ed by ReadDir (fstatat)
+func dirEntries(d *os.File) ([]os.DirEntry, error) {
+	return d.ReadDir(-1)
+}
+
+// Shared extents are only read on Linux, see --shared
+func fileExtents(path string) (int64, int64, bool) {
+	return 0, 0, false
+}
+
+// Quotas are only read on Linux
+func getQuotas(device string) []quota_usage {
+	return nil
+}
+
+// Bind mounts are only detected on Linux, from /proc/self/mountinfo
+func dupMounts(root string, crossFs bool) map[string]string {
+	return nil
+}
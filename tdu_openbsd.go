@@ -0,0 +1,77 @@
+// +build openbsd
+
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+func tcgets() uintptr {
+	return uintptr(syscall.TIOCGETA)
+}
+
+// Ctrl+T sends SIGINFO to the foreground process
+var statusSignals = []os.Signal{syscall.SIGUSR1, syscall.SIGINFO}
+
+/* OpenBSD prefixes the statfs fields with F_ */
+func statFs(path string, st *fs_stat) error {
+	var s syscall.Statfs_t
+	if err := syscall.Statfs(path, &s); err != nil {
+		return err
+	}
+	*st = fs_stat{0, int64(s.F_flags), uint64(s.F_bsize), s.F_blocks,
+		s.F_bfree, uint64(s.F_bavail), s.F_files, s.F_ffree}
+	return nil
+}
+
+func fsMounts() []mount_entry {
+	return statMounts(syscall.Getfsstat, func(st *syscall.Statfs_t) mount_entry {
+		return mount_entry{device: cstr(st.F_mntfromname[:]), dir: cstr(st.F_mntonname[:]),
+			fsType: cstr(st.F_fstypename[:])}
+	})
+}
+
+// Extended attributes are not read on this OS
+func getXattr(path, name string) (string, bool) {
+	return "", false
+}
+
+func xattrSize(path string) int64 {
+	return 0
+}
+
+// Filesystem events are only watched on Linux, see usage()
+func newWatcher(sc *s_scan) scan_watcher { return nil }
+
+func watch(sc *s_scan, fi *[]file, t *file) {}
+
+// Entries of a directory opened in a Root are stat'ed by ReadDir (fstatat)
+func dirEntries(d *os.File) ([]os.DirEntry, error) {
+	return d.ReadDir(-1)
+}
+
+// Shared extents are only read on Linux, see --shared
+func fileExtents(path string) (int64, int64, bool) {
+	return 0, 0, false
+}
+
+// Quotas are only read on Linux
+func getQuotas(device string) []quota_usage {
+	return nil
+}
+
+// Bind mounts are only detected on Linux, from /proc/self/mountinfo
+func dupMounts(root string, crossFs bool) map[string]string {
+	return nil
+}
@@ -0,0 +1,145 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Disk usage per user and group (--by-owner) */
+
+package main
+
+import (
+	"fmt"
+	"os/user"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const dft_MAXOWNERS = 10
+
+type owner_usage struct {
+	id        uint32
+	diskUsage int64
+	size      int64
+	count     int64
+}
+
+func addOwner(m map[uint32]*owner_usage, id uint32, f *file) {
+	u, ok := m[id]
+	if !ok {
+		u = &owner_usage{id: id}
+		m[id] = u
+	}
+	u.diskUsage += f.diskUsage
+	u.size += f.size
+	u.count++
+}
+
+func countOwner(sc *s_scan, f *file) {
+	if sc.owners == nil {
+		return
+	}
+	uid, gid, ok := fileOwner(f)
+	if !ok {
+		return
+	}
+	addOwner(sc.owners, uid, f)
+	addOwner(sc.groups, gid, f)
+}
+
+/* Names of uids and gids, looked up once. Lookups through NSS (LDAP, NIS)
+ * can be slow or hang: after one times out, numbers are printed. */
+var (
+	userNames  = make(map[uint32]string)
+	groupNames = make(map[uint32]string)
+	nssSlow    bool
+)
+
+const dft_LOOKUPTIMEOUT = 2 * time.Second
+
+func lookupName(cache map[uint32]string, id uint32, lookup func(string) (string, error)) string {
+	if n, ok := cache[id]; ok {
+		return n
+	}
+	s := strconv.FormatUint(uint64(id), 10)
+	if nssSlow {
+		return s
+	}
+	c := make(chan string, 1)
+	go func() {
+		if n, err := lookup(s); err == nil && n != "" {
+			c <- n
+			return
+		}
+		c <- s
+	}()
+	select {
+	case n := <-c:
+		cache[id] = n
+		return n
+	case <-time.After(dft_LOOKUPTIMEOUT):
+		nssSlow = true
+		return s
+	}
+}
+
+func userName(id uint32) string {
+	return lookupName(userNames, id, func(s string) (string, error) {
+		u, err := user.LookupId(s)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	})
+}
+
+func groupName(id uint32) string {
+	return lookupName(groupNames, id, func(s string) (string, error) {
+		g, err := user.LookupGroupId(s)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	})
+}
+
+func showOwnerTable(sc *s_scan, title string, m map[uint32]*owner_usage,
+	name func(uint32) string, t int64) {
+	var list []*owner_usage
+	for _, u := range m {
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return shownSize(sc, list[i].diskUsage, list[i].size) >
+			shownSize(sc, list[j].diskUsage, list[j].size)
+	})
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header(title))
+	for i, u := range list {
+		if i >= dft_MAXOWNERS {
+			break
+		}
+		du := shownSize(sc, u.diskUsage, u.size)
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%s\n", du, u.count, name(u.id))
+			continue
+		}
+		p := float64(du*100.0) / float64(t)
+		fmt.Fprintf(sc.out, "%3d. %-12s|%12s|%6.2f%%|%9d %s\n", i+1,
+			smartTruncate(name(u.id), 12), fmtSz(sc, du), p, u.count, tr("items"))
+	}
+}
+
+func showowners(sc *s_scan, total *file) {
+	t := shownSize(sc, total.diskUsage, total.size)
+	if len(sc.owners) == 0 || t == 0 {
+		return
+	}
+	showOwnerTable(sc, "USAGE BY OWNER", sc.owners, userName, t)
+	showOwnerTable(sc, "USAGE BY GROUP", sc.groups, groupName, t)
+}
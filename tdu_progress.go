@@ -0,0 +1,119 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* With --progress-json, the progress line is replaced by events written
+ * as JSON Lines to stderr or to an inherited file descriptor, for the
+ * graphical front-ends and scripts running tdu:
+ *   {"phase":"scan","items":12034,"bytes":...,"dir":"/usr/lib",...}
+ * A scan emits a "start" event, a "scan" event every 500 ms, a "message"
+ * event for each notice (filesystem not crossed...) and a "done" event. */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cst_PROGRESSJSONBEAT = 500 * time.Millisecond
+
+type progress_event struct {
+	Phase    string  `json:"phase"` // start, scan, message or done
+	Root     string  `json:"root"`
+	Items    int64   `json:"items"`
+	Bytes    int64   `json:"bytes"` // disk usage scanned so far
+	Errors   int64   `json:"errors"`
+	Denied   int64   `json:"denied"`
+	Expected int64   `json:"expected,omitempty"` // items to scan, when known
+	Elapsed  float64 `json:"elapsed"`            // seconds since the scan start
+	Dir      string  `json:"dir,omitempty"`
+	Message  string  `json:"message,omitempty"`
+}
+
+/* Writer of the events: "stderr" or the number of an open descriptor */
+func progressWriter(dest string) (io.Writer, error) {
+	if dest == "stderr" {
+		return os.Stderr, nil
+	}
+	fd, err := strconv.Atoi(dest)
+	if err != nil || fd < 0 {
+		return nil, fmt.Errorf(tr("not stderr nor a file descriptor: %q"), dest)
+	}
+	f := os.NewFile(uintptr(fd), "progress")
+	if _, err := f.Stat(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+/* With --progress-json=stderr, stderr only carries the events: the banner
+ * and the console output that would be written there are dropped */
+func stderrEvents(sc *s_scan) {
+	if sc.progressJSON != os.Stderr {
+		return
+	}
+	if sc.info == os.Stderr {
+		sc.info = ioutil.Discard
+	}
+	if sc.con != os.Stderr {
+		return
+	}
+	out := sc.out
+	sc.con = ioutil.Discard
+	sc.progress = false
+	if sc.out != ioutil.Discard { // the console, or the console and --output
+		sc.out = sc.con
+		if sc.report != nil {
+			sc.out = sc.report
+		}
+	}
+	if sc.info == out {
+		sc.info = sc.out
+	}
+}
+
+func progressEvent(sc *s_scan, enc *json.Encoder, phase, msg string) {
+	e := progress_event{Phase: phase, Root: sc.root, Items: sc.nItems, Bytes: sc.nBytes,
+		Errors: sc.nErrors, Denied: sc.nDenied, Expected: sc.expectItems,
+		Elapsed: time.Since(sc.scanStart).Seconds(), Dir: sc.scanning, Message: msg}
+	if phase != "scan" {
+		e.Dir = ""
+	}
+	enc.Encode(e) // a closed reader must not stop the scan
+}
+
+/* Same loop as showProgress, printing events instead of the progress line */
+func showProgressJSON(sc *s_scan) {
+	enc := json.NewEncoder(sc.progressJSON)
+	progressEvent(sc, enc, "start", "")
+	t := time.NewTicker(cst_PROGRESSJSONBEAT)
+	defer t.Stop()
+	for {
+		select {
+		case d := <-sc.curDir:
+			sc.scanning = d
+		case m := <-sc.msg:
+			if m == cst_ENDPROGRESS {
+				progressEvent(sc, enc, "done", "")
+				sc.done <- true
+				return
+			}
+			progressEvent(sc, enc, "message", strings.TrimSpace(m))
+		case <-t.C:
+			progressEvent(sc, enc, "scan", "")
+		}
+	}
+}
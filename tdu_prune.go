@@ -0,0 +1,89 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Presets of directories that can be regenerated, like the dependencies
+ * and outputs of builds (--prune-preset dev). Their usage is summed apart,
+ * or they are skipped with --prune. */
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+var prunePresets = map[string][]string{
+	"dev": {"node_modules", "target", ".venv", "__pycache__", ".gradle", "build"},
+}
+
+type prune_state struct {
+	names  map[string]bool
+	skip   bool // --prune
+	inside int  // depth in a pruned directory
+	total  int64
+	n      int
+	items  []file // largest pruned directories
+}
+
+func newPruneState(preset string, skip bool) *prune_state {
+	names, ok := prunePresets[preset]
+	if !ok {
+		return nil
+	}
+	p := &prune_state{names: make(map[string]bool), skip: skip}
+	for _, n := range names {
+		p.names[n] = true
+	}
+	return p
+}
+
+/* True for a directory of the preset, unless in another one */
+func isPruned(sc *s_scan, fi os.DirEntry) bool {
+	p := sc.prune
+	return p != nil && p.inside == 0 && fi.IsDir() && p.names[fi.Name()]
+}
+
+func pruneAdd(sc *s_scan, f *file) {
+	p := sc.prune
+	p.total += f.diskUsage
+	p.n++
+	if len(p.items) > sc.maxShownLines*4 {
+		sortFiles(sc, p.items)
+		p.items = p.items[:sc.maxShownLines]
+	}
+	p.items = append(p.items, *f)
+}
+
+func showpruned(sc *s_scan) {
+	p := sc.prune
+	if p == nil || p.skip || p.n == 0 {
+		return
+	}
+	sortFiles(sc, p.items)
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("BUILD ARTIFACTS"))
+	for i, f := range p.items {
+		if i >= sc.maxShownLines {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\n", f.diskUsage, fullPath(sc, f.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s| %s\n", i+1, fmtSz(sc, f.diskUsage),
+			smartTruncate(f.path, sc.maxNameLen+18))
+	}
+	if sc.plain {
+		return
+	}
+	fmt.Fprintf(sc.out, tr("  =%13s| of build artifacts under these %d directories\n"),
+		fmtSz(sc, p.total), p.n)
+	fmt.Fprintln(sc.out, tr("  Use --prune to skip them."))
+}
@@ -0,0 +1,192 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Space that standard cleanup commands could free (--reclaimable): the
+ * caches of package managers, user caches and trash, and the kernels
+ * that are not running. Nothing is deleted, the commands are only shown.
+ * Only the places lying in the scanned directory are counted. */
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type reclaim_place struct {
+	name  string   // shown path or item
+	cmd   string   // command that frees the space
+	rels  []string // paths relative to the scanned directory
+	usage int64
+}
+
+/* Paths of the place that exist, relative to root */
+func inScope(root string, paths ...string) []string {
+	var rels []string
+	for _, p := range paths {
+		if _, err := os.Lstat(p); err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(rel, "..") {
+			if !strings.HasPrefix(root, p+string(filepath.Separator)) {
+				continue
+			}
+			rel = "." // the scanned directory is in the place
+		}
+		if rel == "." {
+			rel = ""
+		}
+		rels = append(rels, rel)
+	}
+	return rels
+}
+
+func homeDirs() []string {
+	users, _ := filepath.Glob("/home/*")
+	if h, err := os.UserHomeDir(); err == nil {
+		users = append(users, h)
+	}
+	homes := []string{"/root"}
+	seen := map[string]bool{"/root": true}
+	for _, u := range users {
+		if !seen[u] {
+			seen[u] = true
+			homes = append(homes, u)
+		}
+	}
+	return homes
+}
+
+/* Kernels installed but not running, with the files of each version */
+func oldKernels() map[string][]string {
+	b, err := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return nil
+	}
+	running := strings.TrimSpace(string(b))
+	kernels := make(map[string][]string)
+	for _, d := range []string{"/lib/modules", "/usr/lib/modules"} {
+		vs, _ := ioutil.ReadDir(d)
+		for _, v := range vs {
+			if v.IsDir() && v.Name() != running {
+				kernels[v.Name()] = append(kernels[v.Name()], filepath.Join(d, v.Name()))
+			}
+		}
+	}
+	boot, _ := ioutil.ReadDir("/boot")
+	for _, f := range boot {
+		for v := range kernels {
+			if strings.HasSuffix(f.Name(), "-"+v) || strings.HasSuffix(f.Name(), "-"+v+".img") {
+				kernels[v] = append(kernels[v], filepath.Join("/boot", f.Name()))
+			}
+		}
+	}
+	return kernels
+}
+
+func kernelCmd() string {
+	switch {
+	case fileExists("/usr/bin/apt-get"):
+		return "apt-get autoremove --purge"
+	case fileExists("/usr/bin/dnf"):
+		return "dnf remove --oldinstallonly"
+	}
+	return tr("remove the kernel package")
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+/* Reclaimable places in the scanned directory. A file is counted in the
+ * first place it belongs to, so nested places come first. */
+func findReclaimable(root string) []*reclaim_place {
+	var places []*reclaim_place
+	add := func(name, cmd string, paths ...string) {
+		if rels := inScope(root, paths...); len(rels) > 0 {
+			places = append(places, &reclaim_place{name: name, cmd: cmd, rels: rels})
+		}
+	}
+	add("/var/cache/apt/archives", "apt-get clean", "/var/cache/apt/archives")
+	add("/var/cache/dnf", "dnf clean all", "/var/cache/dnf")
+	add("/var/cache/yum", "yum clean all", "/var/cache/yum")
+	add("/var/cache/pacman/pkg", "pacman -Scc", "/var/cache/pacman/pkg")
+	for _, h := range homeDirs() {
+		add(filepath.Join(h, ".cache/pip"), "pip cache purge", filepath.Join(h, ".cache/pip"))
+		add(filepath.Join(h, ".npm/_cacache"), "npm cache clean --force", filepath.Join(h, ".npm/_cacache"))
+		cache := filepath.Join(h, ".cache")
+		add(cache, "rm -r "+cache+"/*", cache) // ~ would be the home of the invoking user
+		add(filepath.Join(h, ".local/share/Trash"), "gio trash --empty",
+			filepath.Join(h, ".local/share/Trash"))
+	}
+	trash, _ := filepath.Glob("/.Trash-*")
+	for _, t := range trash {
+		add(t, "gio trash --empty", t)
+	}
+	kernels := oldKernels()
+	if len(kernels) > 0 {
+		cmd := kernelCmd()
+		for v, paths := range kernels {
+			add("kernel "+v, cmd, paths...)
+		}
+	}
+	return places
+}
+
+func countReclaim(sc *s_scan, f *file) {
+	if len(sc.reclaim) == 0 || f.diskUsage == 0 {
+		return
+	}
+	for _, p := range sc.reclaim {
+		for _, r := range p.rels {
+			if r == "" || f.path == r || strings.HasPrefix(f.path, r+sc.pathSeparator) {
+				p.usage += f.diskUsage
+				return
+			}
+		}
+	}
+}
+
+func showreclaim(sc *s_scan) {
+	var list []*reclaim_place
+	var total int64
+	for _, p := range sc.reclaim {
+		if p.usage > 0 {
+			list = append(list, p)
+			total += p.usage
+		}
+	}
+	if len(list) == 0 {
+		return
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].usage > list[j].usage })
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("RECLAIMABLE SPACE"))
+	for i, p := range list {
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\t%s\n", p.usage, p.name, p.cmd)
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s| %-28s| %s\n", i+1, fmtSz(sc, p.usage), p.cmd,
+			smartTruncate(p.name, sc.maxNameLen))
+	}
+	if !sc.plain {
+		fmt.Fprintf(sc.out, tr("  =%13s| could be freed, nothing was deleted\n"), fmtSz(sc, total))
+	}
+}
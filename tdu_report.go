@@ -0,0 +1,259 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Machine readable reports (--format). A reporter receives the rows of
+ * the main table and of the biggest files, instead of the text tables.
+ * Markdown (--markdown) gives GitHub tables to paste into issues.
+ * The line protocols of InfluxDB and Graphite (--influx, --graphite) only
+ * keep the depth 1 items and the total, timestamped with the start of the
+ * run, to feed dashboards of the growth of directories. */
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type report_row struct {
+	path      string
+	size      int64
+	diskUsage int64
+	items     int64
+	kind      string // dir, file, symlink, special, or bigfile
+}
+
+type reporter interface {
+	write(sc *s_scan, rows []report_row, total *file) error
+}
+
+var reporters = map[string]reporter{
+	"csv":      csvReporter{','},
+	"tsv":      csvReporter{'\t'},
+	"influx":   influxReporter{},
+	"graphite": graphiteReporter{},
+	"markdown": markdownReporter{},
+}
+
+func formatNames() []string {
+	var names []string
+	for n := range reporters {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/* Rows shown by show() and showmax() in the text report */
+func reportRows(sc *s_scan, fi []file) []report_row {
+	var rows []report_row
+	sortTable(sc, fi)
+	i := 0
+	for _, f := range fi {
+		if !f.isDir && sc.nFiles == 0 { // ignore special files
+			continue
+		}
+		i++
+		if i > sc.maxShownLines {
+			break
+		}
+		rows = append(rows, report_row{fullPath(sc, f.path), f.size,
+			f.diskUsage, f.items, fileType(&f)})
+	}
+	sortFiles(sc, sc.bigfiles)
+	for i, f := range sc.bigfiles {
+		if i >= sc.maxBigFiles {
+			break
+		}
+		rows = append(rows, report_row{fullPath(sc, f.path), f.size,
+			f.diskUsage, 0, "bigfile"})
+	}
+	return rows
+}
+
+type csvReporter struct {
+	comma rune
+}
+
+func (r csvReporter) write(sc *s_scan, rows []report_row, total *file) error {
+	w := csv.NewWriter(sc.rep)
+	w.Comma = r.comma
+	w.Write([]string{"path", "size", "disk_usage", "items", "type"})
+	for _, row := range rows {
+		w.Write([]string{row.path, strconv.FormatInt(row.size, 10),
+			strconv.FormatInt(row.diskUsage, 10),
+			strconv.FormatInt(row.items, 10), row.kind})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+type influxReporter struct{}
+
+var influxEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+
+/* tdu,root=/home,path=/home/joe disk_usage=123i,size=456i,items=7i <ns> */
+func (influxReporter) write(sc *s_scan, rows []report_row, total *file) error {
+	root := influxEscaper.Replace(sc.root)
+	ts := sc.start.UnixNano()
+	if sc.deterministic {
+		ts = 0
+	}
+	for _, row := range rows {
+		if row.kind == "bigfile" {
+			continue
+		}
+		fmt.Fprintf(sc.rep, "tdu,root=%s,path=%s,type=%s disk_usage=%di,size=%di,items=%di %d\n",
+			root, influxEscaper.Replace(row.path), row.kind, row.diskUsage, row.size,
+			row.items, ts)
+	}
+	_, err := fmt.Fprintf(sc.rep, "tdu_total,root=%s disk_usage=%di,size=%di,items=%di %d\n",
+		root, total.diskUsage, total.size, total.items, ts)
+	return err
+}
+
+type graphiteReporter struct{}
+
+var graphiteUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+/* Path components become nodes of the metric name: /home -> home, and
+ * / -> _ as a node cannot be empty */
+func graphiteName(s string) string {
+	s = strings.Trim(filepath.ToSlash(s), "/")
+	if s == "" {
+		return "_"
+	}
+	var nodes []string
+	for _, n := range strings.Split(s, "/") {
+		nodes = append(nodes, graphiteUnsafe.ReplaceAllString(n, "_"))
+	}
+	return strings.Join(nodes, ".")
+}
+
+/* tdu.home.joe.disk_usage 123 <seconds> */
+func (graphiteReporter) write(sc *s_scan, rows []report_row, total *file) error {
+	ts := sc.start.Unix()
+	if sc.deterministic {
+		ts = 0
+	}
+	m := "tdu." + graphiteName(sc.root)
+	for _, row := range rows {
+		if row.kind == "bigfile" {
+			continue
+		}
+		n := "tdu." + graphiteName(row.path)
+		fmt.Fprintf(sc.rep, "%s.disk_usage %d %d\n", n, row.diskUsage, ts)
+		fmt.Fprintf(sc.rep, "%s.size %d %d\n", n, row.size, ts)
+	}
+	fmt.Fprintf(sc.rep, "%s.total.disk_usage %d %d\n", m, total.diskUsage, ts)
+	fmt.Fprintf(sc.rep, "%s.total.size %d %d\n", m, total.size, ts)
+	_, err := fmt.Fprintf(sc.rep, "%s.total.items %d %d\n", m, total.items, ts)
+	return err
+}
+
+type markdownReporter struct{}
+
+/* Path in a code span, so that * or _ in names are not markup */
+func mdCode(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
+
+func (markdownReporter) write(sc *s_scan, rows []report_row, total *file) error {
+	w := sc.rep
+	fmt.Fprintf(w, "## tdu %s\n\n", mdCode(sc.root))
+	fmt.Fprintf(w, "| # | %s | %s | %s | %% | %s |\n", tr("Item"), tr("Disk"), tr("Apparent"),
+		tr("Items"))
+	fmt.Fprintln(w, "|--:|:---|---:|---:|---:|---:|")
+	t := shownSize(sc, total.diskUsage, total.size)
+	var n int
+	for _, row := range rows {
+		if row.kind == "bigfile" {
+			continue
+		}
+		n++
+		var p float64
+		if t > 0 {
+			p = float64(shownSize(sc, row.diskUsage, row.size)) * 100 / float64(t)
+		}
+		name := filepath.Base(row.path)
+		items := ""
+		if row.kind == "dir" {
+			name += sc.pathSeparator
+			items = strconv.FormatInt(row.items, 10)
+		}
+		fmt.Fprintf(w, "| %d | %s | %s | %s | %.2f%% | %s |\n", n, mdCode(name),
+			fmtSz(sc, row.diskUsage), fmtSz(sc, row.size), p, items)
+	}
+	n = 0
+	for _, row := range rows {
+		if row.kind != "bigfile" {
+			continue
+		}
+		if n == 0 {
+			fmt.Fprintf(w, "\n### %s\n\n", tr("Biggest files"))
+			fmt.Fprintf(w, "| # | %s | %s | %s |\n", tr("File"), tr("Disk"), tr("Apparent"))
+			fmt.Fprintln(w, "|--:|:---|---:|---:|")
+		}
+		n++
+		fmt.Fprintf(w, "| %d | %s | %s | %s |\n", n, mdCode(row.path),
+			fmtSz(sc, row.diskUsage), fmtSz(sc, row.size))
+	}
+	fmt.Fprintf(w, "\n### %s\n\n", tr("Summary"))
+	fmt.Fprintln(w, "| | |\n|:---|---:|")
+	summary := [][2]string{
+		{tr("Disk usage"), fmtSz(sc, total.diskUsage)},
+		{tr("Apparent size"), fmtSz(sc, total.size)},
+		{tr("Items"), strconv.FormatInt(sc.nItems, 10)},
+		{tr("Directories"), strconv.FormatInt(sc.nDirs, 10)},
+		{tr("Files"), strconv.FormatInt(sc.nFiles, 10)},
+		{tr("Denied"), strconv.FormatInt(sc.nDenied, 10)},
+		{tr("Errors"), strconv.FormatInt(sc.nErrors, 10)},
+	}
+	if !sc.deterministic {
+		summary = append(summary, [2]string{tr("Scan time"),
+			fmt.Sprintf("%.3f s", time.Since(sc.start).Seconds())})
+	}
+	var err error
+	for _, l := range summary {
+		_, err = fmt.Fprintf(w, "| %s | %s |\n", l[0], l[1])
+	}
+	return err
+}
+
+/* Output of du -skx: disk usage in kilobytes rounded up, then the path.
+ * All depth 1 entries are written, followed by the scanned directory. */
+func writeDu(sc *s_scan, fi []file, total *file) {
+	sortTable(sc, fi)
+	for _, f := range fi {
+		writeDuLine(sc, &f)
+	}
+	fmt.Fprintf(sc.rep, "%d\t%s\n", duKb(sc, total), sc.root)
+}
+
+func writeDuLine(sc *s_scan, f *file) {
+	for _, k := range f.kids { // du prints the content before the directory
+		writeDuLine(sc, &k)
+	}
+	fmt.Fprintf(sc.rep, "%d\t%s\n", duKb(sc, f), fullPath(sc, f.path))
+}
+
+func duKb(sc *s_scan, f *file) int64 {
+	return (shownSize(sc, f.diskUsage, f.size) + 1023) / 1024
+}
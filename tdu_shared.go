@@ -0,0 +1,85 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* On copy-on-write filesystems (btrfs, XFS), reflinked copies, snapshots
+ * and deduplicated files share their extents, which are counted once per
+ * file by du. With --shared, the extents of each file are read (FIEMAP on
+ * Linux) to show, like 'btrfs filesystem du', the part of each depth 1
+ * item that is exclusive to it and the part that is shared. */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type extent_usage struct {
+	name   string
+	total  int64 // bytes of all extents
+	shared int64 // bytes of extents shared with other files
+}
+
+func countShared(sc *s_scan, f *file) {
+	// hardlinks already seen have no disk usage, their extents neither
+	if sc.shared == nil || !f.isRegular || f.diskUsage == 0 || f.isOtherFs {
+		return
+	}
+	total, shared, ok := sc.fs.fileExtents(f.path)
+	if !ok {
+		return
+	}
+	top := strings.SplitN(f.path, sc.pathSeparator, 2)[0]
+	u, ok := sc.shared[top]
+	if !ok {
+		u = &extent_usage{name: top}
+		sc.shared[top] = u
+	}
+	u.total += total
+	u.shared += shared
+}
+
+func showshared(sc *s_scan) {
+	if len(sc.shared) == 0 {
+		return
+	}
+	var list []*extent_usage
+	var total, shared int64
+	for _, u := range sc.shared {
+		list = append(list, u)
+		total += u.total
+		shared += u.shared
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].total > list[j].total })
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("SHARED EXTENTS"))
+	if !sc.plain {
+		fmt.Fprint(sc.out, tr("           Total|   Exclusive|      Shared|\n"))
+	}
+	for i, u := range list {
+		if i >= sc.maxShownLines {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%d\t%s\n", u.total, u.total-u.shared,
+				u.shared, fullPath(sc, u.name))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s|%12s|%12s| %s\n", i+1, fmtSz(sc, u.total),
+			fmtSz(sc, u.total-u.shared), fmtSz(sc, u.shared),
+			smartTruncate(u.name, sc.maxNameLen))
+	}
+	if sc.plain {
+		return
+	}
+	fmt.Fprintf(sc.out, tr("  =%13s|%12s|%12s| in all files\n"), fmtSz(sc, total),
+		fmtSz(sc, total-shared), fmtSz(sc, shared))
+}
@@ -0,0 +1,80 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Space lost to the rounding of files to whole blocks (--slack), per
+ * depth 1 item. On filesystems with large blocks, millions of tiny files
+ * can waste more space than they hold. */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type slack_usage struct {
+	name      string
+	slack     int64 // allocated beyond the apparent size
+	diskUsage int64 // of all files
+	count     int64 // files with slack
+}
+
+func countSlack(sc *s_scan, f *file) {
+	if sc.slack == nil || !f.isRegular {
+		return
+	}
+	top := strings.SplitN(f.path, sc.pathSeparator, 2)[0]
+	u, ok := sc.slack[top]
+	if !ok {
+		u = &slack_usage{name: top}
+		sc.slack[top] = u
+	}
+	u.diskUsage += f.diskUsage
+	if f.diskUsage > f.size { // sparse and compressed files allocate less
+		u.slack += f.diskUsage - f.size
+		u.count++
+	}
+}
+
+func showslack(sc *s_scan, total *file) {
+	if len(sc.slack) == 0 || total.diskUsage == 0 {
+		return
+	}
+	var list []*slack_usage
+	var slack int64
+	for _, u := range sc.slack {
+		list = append(list, u)
+		slack += u.slack
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].slack > list[j].slack })
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("SLACK SPACE"))
+	if !sc.plain {
+		fmt.Fprint(sc.out, tr("           Slack| % of du|     Files|\n"))
+	}
+	for i, u := range list {
+		if i >= sc.maxShownLines || u.slack == 0 {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%s\n", u.slack, u.count, fullPath(sc, u.name))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s|%7.2f%%|%10d| %s\n", i+1, fmtSz(sc, u.slack),
+			float64(u.slack)*100/float64(u.diskUsage), u.count,
+			smartTruncate(u.name, sc.maxNameLen))
+	}
+	if sc.plain {
+		return
+	}
+	fmt.Fprintf(sc.out, tr("  =%13s|%7.2f%%| of the total disk usage\n"), fmtSz(sc, slack),
+		float64(slack)*100/float64(total.diskUsage))
+}
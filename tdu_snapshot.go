@@ -0,0 +1,124 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Native snapshots (--save/--load) keep the whole tree of a scan with
+ * sizes, inode numbers and device ids, gob encoded and gzipped, so the
+ * results can be shown again later without scanning. */
+
+package main
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"time"
+)
+
+const snapshot_VERSION = 1
+
+type snapshot struct {
+	Version int
+	Program string // version of tdu that made the scan
+	OS      string
+	Time    time.Time
+	Root    *node
+}
+
+/* Adds a scanned item to the tree, directories become the parent of
+ * the next items until snapCloseDir() */
+func snapAdd(sc *s_scan, f *file) {
+	if sc.savePath == "" {
+		return
+	}
+	du, hl := ncduDiskUsage(sc, f)
+	n := &node{Name: f.name, Size: f.size, DiskUsage: du, Device: f.deviceId,
+		Inode: f.inode, Hardlink: hl, NotReg: !f.isDir && !f.isRegular,
+		ReadError: f.readError, IsDir: f.isDir, Symlink: f.isSymlink}
+	if f.depth == 1 {
+		n.Name = sc.root
+	}
+	if f.isOtherFs {
+		n.Excluded = "othfs"
+		n.Size, n.DiskUsage = 0, 0
+	}
+	if l := len(sc.snapDirs); l > 0 {
+		p := sc.snapDirs[l-1]
+		p.Children = append(p.Children, n)
+	} else {
+		sc.snapRoot = n
+	}
+	if f.isDir && !f.isOtherFs {
+		sc.snapDirs = append(sc.snapDirs, n)
+	}
+}
+
+func snapCloseDir(sc *s_scan) {
+	if l := len(sc.snapDirs); l > 0 {
+		sc.snapDirs = sc.snapDirs[:l-1]
+	}
+}
+
+func saveSnapshot(sc *s_scan) {
+	if sc.savePath == "" || sc.snapRoot == nil {
+		return
+	}
+	s := snapshot{Version: snapshot_VERSION, Program: prg_VERSION, OS: sc.os,
+		Time: sc.start, Root: sc.snapRoot}
+	f, err := os.Create(sc.savePath)
+	if err == nil {
+		z := gzip.NewWriter(f)
+		err = gob.NewEncoder(z).Encode(&s)
+		if e := z.Close(); err == nil {
+			err = e
+		}
+		if e := f.Close(); err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(sc.out, tr("  [ERROR] Cannot save snapshot: %v\n"), err)
+	}
+}
+
+func readSnapshot(path string) (*snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	z, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: not a tdu snapshot", path)
+	}
+	var s snapshot
+	if err := gob.NewDecoder(z).Decode(&s); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if s.Version != snapshot_VERSION || s.Root == nil {
+		return nil, fmt.Errorf("%s: unsupported snapshot version", path)
+	}
+	return &s, nil
+}
+
+/* Shows the results of a saved scan */
+func load(sc *s_scan) {
+	s, err := readSnapshot(sc.loadPath)
+	if err != nil {
+		fmt.Fprintf(sc.out, tr("  [ERROR] Cannot load snapshot: %v\n"), err)
+		return
+	}
+	if !sc.quiet {
+		fmt.Fprintf(sc.out, tr("  OS: %s, loaded [%s] scanned on %s\n\n"), s.OS,
+			s.Root.Name, s.Time.Format("2006-01-02 15:04:05"))
+	}
+	fi, t := replay(sc, s.Root)
+	showResults(sc, fi, t)
+}
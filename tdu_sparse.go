@@ -0,0 +1,68 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Sparse files (-p): regular files allocating far less than their apparent
+ * size, such as VM images or core dumps. They explain why du and ls disagree. */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+const cst_SPARSEMIN = 1024 * 1024 // unallocated bytes for a file to be reported
+
+/* A file is sparse when less than half of its apparent size is allocated */
+func isSparse(size, allocated int64) bool {
+	return size-allocated >= cst_SPARSEMIN && allocated < size/2
+}
+
+func sortSparse(fi []file) {
+	sort.Slice(fi, func(i, j int) bool {
+		return fi[i].size-fi[i].diskUsage > fi[j].size-fi[j].diskUsage
+	})
+}
+
+func countSparse(sc *s_scan, f *file) {
+	if !f.isSparse {
+		return
+	}
+	sc.nSparse++
+	if sc.maxSparse <= 0 {
+		return
+	}
+	if len(sc.sparsefiles) > sc.maxSparse*4 {
+		sortSparse(sc.sparsefiles)
+		sc.sparsefiles = sc.sparsefiles[0:sc.maxSparse]
+	}
+	sc.sparsefiles = append(sc.sparsefiles, *f)
+}
+
+func showsparse(sc *s_scan) {
+	if sc.maxSparse <= 0 || len(sc.sparsefiles) == 0 {
+		return
+	}
+	sortSparse(sc.sparsefiles)
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("SPARSE FILES"))
+	for i, f := range sc.sparsefiles {
+		i++
+		if i > sc.maxSparse {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%s\n", f.diskUsage, f.size, fullPath(sc, f.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, tr("%3d.%12s| of %10s| %s\n"), i, fmtSz(sc, f.diskUsage),
+			fmtSz(sc, f.size), smartTruncate(f.path, sc.maxNameLen))
+	}
+}
@@ -0,0 +1,215 @@
+// +build linux
+
+/* Top Disk Usage.
+ * Copyright (C) 2019 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* statx(2) is available since Linux 4.11. It only fetches the fields used
+ * by tdu, and the birth time of files when the filesystem records it. */
+
+package main
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	at_FDCWD            = -100
+	at_SYMLINK_NOFOLLOW = 0x100
+	at_NO_AUTOMOUNT     = 0x800
+
+	statx_TYPE   = 0x1
+	statx_MODE   = 0x2
+	statx_NLINK  = 0x4
+	statx_UID    = 0x8
+	statx_GID    = 0x10
+	statx_MTIME  = 0x40
+	statx_INO    = 0x100
+	statx_SIZE   = 0x200
+	statx_BLOCKS = 0x400
+	statx_BTIME  = 0x800
+
+	statx_MASK = statx_TYPE | statx_MODE | statx_NLINK | statx_UID | statx_GID |
+		statx_MTIME | statx_INO | statx_SIZE | statx_BLOCKS | statx_BTIME
+)
+
+// The syscall package only knows statx on a few architectures
+var sys_STATX = map[string]uintptr{
+	"386": 383, "amd64": 332, "arm": 397, "arm64": 291, "loong64": 291,
+	"mips": 4366, "mipsle": 4366, "mips64": 5326, "mips64le": 5326,
+	"ppc64": 383, "ppc64le": 383, "riscv64": 291, "s390x": 379,
+}[runtime.GOARCH]
+
+type statx_time struct {
+	sec  int64
+	nsec uint32
+	_    int32
+}
+
+type statx_t struct { // struct statx, the same on all architectures
+	mask       uint32
+	blksize    uint32
+	attributes uint64
+	nlink      uint32
+	uid        uint32
+	gid        uint32
+	mode       uint16
+	_          uint16
+	ino        uint64
+	size       uint64
+	blocks     uint64
+	attrMask   uint64
+	atime      statx_time
+	btime      statx_time
+	ctime      statx_time
+	mtime      statx_time
+	rdevMajor  uint32
+	rdevMinor  uint32
+	devMajor   uint32
+	devMinor   uint32
+	_          [14]uint64
+}
+
+func statx(dirfd int, name string, st *statx_t) error {
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return err
+	}
+	_, _, e := syscall.Syscall6(sys_STATX, uintptr(dirfd), uintptr(unsafe.Pointer(p)),
+		at_SYMLINK_NOFOLLOW|at_NO_AUTOMOUNT, statx_MASK, uintptr(unsafe.Pointer(st)), 0)
+	if e != 0 {
+		return e
+	}
+	return nil
+}
+
+// Older kernels (ENOSYS) and some container sandboxes (EPERM) refuse statx
+var has_STATX = sys_STATX != 0 && statx(at_FDCWD, "/", &statx_t{}) == nil
+
+/* Stat_t fields have different sizes depending on the architecture */
+func setField[T ~int32 | ~int64 | ~uint32 | ~uint64](p *T, v uint64) {
+	*p = T(v)
+}
+
+/* Same device number as the one returned by stat(2), see makedev(3) */
+func mkdev(major, minor uint32) uint64 {
+	ma, mi := uint64(major), uint64(minor)
+	return (ma&0xfffff000)<<32 | (ma&0xfff)<<8 | (mi&0xffffff00)<<12 | mi&0xff
+}
+
+type statx_info struct { // os.FileInfo made from a statx, see dirEntries()
+	name  string
+	mode  os.FileMode
+	mtime time.Time
+	btime time.Time // zero if the filesystem does not record it
+	st    syscall.Stat_t
+}
+
+func (fi *statx_info) Name() string         { return fi.name }
+func (fi *statx_info) Size() int64          { return fi.st.Size }
+func (fi *statx_info) Mode() os.FileMode    { return fi.mode }
+func (fi *statx_info) ModTime() time.Time   { return fi.mtime }
+func (fi *statx_info) IsDir() bool          { return fi.mode.IsDir() }
+func (fi *statx_info) Sys() interface{}     { return &fi.st }
+func (fi *statx_info) birthTime() time.Time { return fi.btime }
+
+/* Same file mode as the one built by os.Lstat */
+func fileMode(m uint16) os.FileMode {
+	mode := os.FileMode(m & 0777)
+	switch m & syscall.S_IFMT {
+	case syscall.S_IFBLK:
+		mode |= os.ModeDevice
+	case syscall.S_IFCHR:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case syscall.S_IFDIR:
+		mode |= os.ModeDir
+	case syscall.S_IFIFO:
+		mode |= os.ModeNamedPipe
+	case syscall.S_IFLNK:
+		mode |= os.ModeSymlink
+	case syscall.S_IFSOCK:
+		mode |= os.ModeSocket
+	}
+	if m&syscall.S_ISGID != 0 {
+		mode |= os.ModeSetgid
+	}
+	if m&syscall.S_ISUID != 0 {
+		mode |= os.ModeSetuid
+	}
+	if m&syscall.S_ISVTX != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+func newStatxInfo(name string, x *statx_t) *statx_info {
+	fi := &statx_info{name: name, mode: fileMode(x.mode),
+		mtime: time.Unix(x.mtime.sec, int64(x.mtime.nsec))}
+	if x.mask&statx_BTIME != 0 && x.btime.sec != 0 { // 0 on some overlays
+		fi.btime = time.Unix(x.btime.sec, int64(x.btime.nsec))
+	}
+	st := &fi.st
+	setField(&st.Dev, mkdev(x.devMajor, x.devMinor))
+	setField(&st.Rdev, mkdev(x.rdevMajor, x.rdevMinor))
+	setField(&st.Nlink, uint64(x.nlink))
+	setField(&st.Blksize, uint64(x.blksize))
+	st.Ino = x.ino
+	st.Mode = uint32(x.mode)
+	st.Uid = x.uid
+	st.Gid = x.gid
+	st.Size = int64(x.size)
+	st.Blocks = int64(x.blocks)
+	st.Mtim = syscall.NsecToTimespec(fi.mtime.UnixNano())
+	return fi
+}
+
+type statx_entry struct { // os.DirEntry with the statx of the entry
+	os.DirEntry
+	fi  os.FileInfo
+	err error
+}
+
+func (e *statx_entry) Info() (os.FileInfo, error) { return e.fi, e.err }
+
+/* Entries of a directory opened in a Root. ReadDir would stat each of
+ * them with fstatat: they are listed from a duplicate of the handle,
+ * which is not in the Root, then stat'ed with statx relative to it. */
+func dirEntries(d *os.File) ([]os.DirEntry, error) {
+	if !has_STATX {
+		return d.ReadDir(-1) // fstatat, see above
+	}
+	fd := int(d.Fd())
+	dup, err := syscall.Dup(fd)
+	if err != nil {
+		return nil, err
+	}
+	l := os.NewFile(uintptr(dup), d.Name())
+	defer l.Close()
+	es, err := l.ReadDir(-1)
+	if err != nil {
+		return nil, err
+	}
+	r := es[:0]
+	var x statx_t
+	for _, e := range es {
+		if err := statx(fd, e.Name(), &x); err != nil {
+			if err == syscall.ENOENT {
+				continue // removed meanwhile, as ReadDir does
+			}
+			r = append(r, &statx_entry{DirEntry: e, err: err})
+			continue
+		}
+		r = append(r, &statx_entry{DirEntry: e, fi: newStatxInfo(e.Name(), &x)})
+	}
+	return r, nil
+}
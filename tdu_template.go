@@ -0,0 +1,109 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Reports written by a Go template (--template file.tmpl), for formats
+ * tdu does not know. The template receives a tmpl_result, e.g.:
+ *   {{range .Entries}}| {{.Name}} | {{size .DiskUsage}} |
+ *   {{end}}Total: {{size .Total.DiskUsage}} in {{.Files}} files
+ * with the functions size (human readable size) and trunc (path
+ * shortened to n characters). */
+
+package main
+
+import (
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+type tmpl_entry struct {
+	Path      string // full path
+	Name      string
+	Type      string // dir, file, symlink or special
+	Size      int64
+	DiskUsage int64
+	Items     int64
+	Percent   float64 // of the total disk usage
+}
+
+type tmpl_partition struct {
+	Device string
+	FsType string
+	Size   int64 // 0 if unknown
+	Avail  int64
+	Used   int64 // as df, the blocks reserved to root are not counted
+}
+
+type tmpl_result struct {
+	Root      string
+	OS        string
+	Start     time.Time
+	Elapsed   float64      // seconds
+	Entries   []tmpl_entry // the main table
+	BigFiles  []tmpl_entry
+	Total     tmpl_entry
+	Items     int64
+	Files     int64
+	Dirs      int64
+	Symlinks  int64
+	Hardlinks int64
+	EmptyDirs int64
+	Errors    int64
+	Denied    int64
+	Excluded  int64
+	Truncated bool // a budget was reached or the scan was interrupted
+	Partition tmpl_partition
+}
+
+type templateReporter struct {
+	t *template.Template
+}
+
+func parseTemplate(sc *s_scan, path string) (*template.Template, error) {
+	return template.New(filepath.Base(path)).Funcs(template.FuncMap{
+		"size":  func(n int64) string { return fmtSz(sc, n) },
+		"trunc": func(s string, n int) string { return smartTruncate(s, n) },
+	}).ParseFiles(path)
+}
+
+func tmplEntry(row report_row, total *file) tmpl_entry {
+	e := tmpl_entry{Path: row.path, Name: filepath.Base(row.path), Type: row.kind,
+		Size: row.size, DiskUsage: row.diskUsage, Items: row.items}
+	if total.diskUsage > 0 {
+		e.Percent = float64(row.diskUsage) * 100 / float64(total.diskUsage)
+	}
+	return e
+}
+
+func (r templateReporter) write(sc *s_scan, rows []report_row, total *file) error {
+	res := tmpl_result{Root: sc.root, OS: sc.os, Start: sc.start,
+		Elapsed: time.Since(sc.start).Seconds(), Items: sc.nItems, Files: sc.nFiles,
+		Dirs: sc.nDirs, Symlinks: sc.nSymlinks, Hardlinks: sc.nHardlinks,
+		EmptyDirs: sc.nEmptyDir, Errors: sc.nErrors, Denied: sc.nDenied,
+		Excluded: sc.nExcluded, Truncated: sc.truncated || sc.interrupted,
+		Partition: tmpl_partition{Device: sc.partition, FsType: sc.fsType,
+			Size: sc.partSize, Avail: sc.partAvail, Used: sc.partSize - sc.partFree}}
+	if sc.deterministic {
+		res.Start, res.Elapsed = time.Time{}, 0
+		res.Partition.Size, res.Partition.Avail, res.Partition.Used = 0, 0, 0
+	}
+	for _, row := range rows {
+		if row.kind == "bigfile" {
+			e := tmplEntry(row, total)
+			e.Type = "file"
+			res.BigFiles = append(res.BigFiles, e)
+			continue
+		}
+		res.Entries = append(res.Entries, tmplEntry(row, total))
+	}
+	res.Total = tmplEntry(report_row{sc.root, total.size, total.diskUsage, total.items,
+		"dir"}, total)
+	return r.t.Execute(sc.rep, res)
+}
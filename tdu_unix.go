@@ -1,4 +1,4 @@
-// +build linux freebsd
+// +build linux freebsd darwin openbsd netbsd
 
 /* Top Disk Usage.
  * Copyright (C) 2019 Joseph Paul <joseph.paul1@gmx.com>
@@ -15,7 +15,9 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"syscall"
@@ -35,6 +37,25 @@ var mntFlag = map[int64]string{
 	0x1000: "RELATIME",   /* update atime relative to mtime/ctime */
 }
 
+/* Mounted filesystem, as listed by getfsstat on BSD systems */
+type mount_entry struct {
+	device string
+	dir    string
+	fsType string
+}
+
+/* Filesystem statistics, whatever the layout of statfs on this OS */
+type fs_stat struct {
+	Type   int64
+	Flags  int64
+	Bsize  uint64
+	Blocks uint64
+	Bfree  uint64
+	Bavail uint64
+	Files  uint64
+	Ffree  uint64
+}
+
 func readFlags(f int64) string {
 	s := ""
 	i := 0
@@ -176,18 +197,30 @@ func osEnd(sys interface{}) bool {
 }
 
 func initTty(sc *s_scan) {
-	sc.tty = isTty()
-	if sc.tty {
-		fmt.Print("\033[H\033[2J") // Clear the console
+	fd := syscall.Stdout
+	if sc.con == os.Stderr { // stdout is used by a stream (-o -, --jsonl)
+		fd = syscall.Stderr
+	}
+	sc.tty = isTty(fd)
+	sc.progress = sc.tty
+	sc.info = sc.out
+	if fd == syscall.Stdout && !sc.tty { // tdu > report.txt: only the report in the file
+		sc.info = os.Stderr
+		if isTty(syscall.Stderr) {
+			sc.con = os.Stderr
+			sc.progress = true
+		}
+	}
+	if sc.tty && !sc.quiet {
+		fmt.Fprint(sc.con, "\033[H\033[2J") // Clear the console
 	}
 }
 
-func isTty() bool {
+func isTty(fd int) bool {
 	var term syscall.Termios
 	p := uintptr(unsafe.Pointer(&term))
-	stdout := uintptr(syscall.Stdout)
 	cmd := tcgets()
-	r1, _, _ := syscall.Syscall(syscall.SYS_IOCTL, stdout, cmd, p)
+	r1, _, _ := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), cmd, p)
 	if int(r1) == -1 {
 		return false
 	}
@@ -212,40 +245,62 @@ const (
 */
 )
 
-func cls()          { fmt.Printf(clear_SCREEN) }
-func colorDefault() { fmt.Printf(color_DEFAULT) }
-func colorGreen()   { fmt.Printf(color_GREEN) }
-func colorBlue()    { fmt.Printf(color_BLUE) }
-func colorRed()     { fmt.Printf(color_RED) }
-func colorYellow()  { fmt.Printf(color_YELLOW) }
-func colorCyan()    { fmt.Printf(color_CYAN) }
-func colorMagenta() { fmt.Printf(color_MAGENTA) }
-func colorAlert()   { fmt.Printf(color_ALERT) }
+const ( // Growth markers in the table
+	arrow_UP   = "▲"
+	arrow_DOWN = "▼"
+	bar_CHAR   = "█" // bar charts
+	bar_FULL   = "▓" // bars of the main table
+	bar_EMPTY  = "░"
+)
+
+func cls(sc *s_scan)          { fmt.Fprint(sc.con, clear_SCREEN) }
+func colorDefault(sc *s_scan) { fmt.Fprint(sc.con, color_DEFAULT) }
+func colorGreen(sc *s_scan)   { fmt.Fprint(sc.con, color_GREEN) }
+func colorBlue(sc *s_scan)    { fmt.Fprint(sc.con, color_BLUE) }
+func colorRed(sc *s_scan)     { fmt.Fprint(sc.con, color_RED) }
+func colorYellow(sc *s_scan)  { fmt.Fprint(sc.con, color_YELLOW) }
+func colorCyan(sc *s_scan)    { fmt.Fprint(sc.con, color_CYAN) }
+func colorMagenta(sc *s_scan) { fmt.Fprint(sc.con, color_MAGENTA) }
+func colorAlert(sc *s_scan)   { fmt.Fprint(sc.con, color_ALERT) }
 
 func printAlert(sc *s_scan, msg string) {
 	if sc.tty {
-		colorRed()
+		colorRed(sc)
 	}
-	fmt.Printf(msg)
+	fmt.Fprint(sc.out, msg)
 	if sc.tty {
-		colorDefault()
+		colorDefault(sc)
+	}
+}
+
+func printShare(sc *s_scan, level int, msg string) {
+	switch level {
+	case share_HIGH:
+		colorRed(sc)
+	case share_MID:
+		colorYellow(sc)
+	default:
+		fmt.Fprint(sc.out, msg)
+		return
 	}
+	fmt.Fprint(sc.out, msg)
+	colorDefault(sc)
 }
 
 func printProgress(sc *s_scan) {
-	if !sc.tty {
+	if !sc.progress {
 		return
 	}
-	fmt.Printf("  [.... scanning... ")
+	fmt.Fprint(sc.con, tr("  [.... scanning... "))
 	n := sc.nErrors + sc.nItems
 	if sc.nErrors > 0 {
-		colorYellow()
+		colorYellow(sc)
 	} else {
-		colorGreen()
+		colorGreen(sc)
 	}
-	fmt.Printf("%6d", n)
-	colorDefault()
-	fmt.Printf("  ....]\r")
+	fmt.Fprintf(sc.con, "%6d", n)
+	colorDefault(sc)
+	fmt.Fprintf(sc.con, "%s\r", progressInfo(sc))
 }
 
 func getTtyWidth(sc *s_scan) int {
@@ -270,6 +325,23 @@ func getTtyWidth(sc *s_scan) int {
 	return int(ws.Col)
 }
 
+/* SIGWINCH is sent when the terminal is resized */
+func watchResize(sc *s_scan) {
+	if !sc.tty {
+		return
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGWINCH)
+	go func() {
+		for range c {
+			select {
+			case sc.resize <- true:
+			default: // a resize is already pending
+			}
+		}
+	}()
+}
+
 func scanMount(sc *s_scan) bool {
 	if sc.partinfo == false {
 		return false
@@ -302,11 +374,184 @@ func scanMount(sc *s_scan) bool {
 	return false
 }
 
+/* Tells whether the scanned directory is the root of its filesystem */
+func fsRoot(sc *s_scan) bool {
+	var st, parent syscall.Stat_t
+	if syscall.Lstat(".", &st) != nil || syscall.Lstat("..", &parent) != nil {
+		return false
+	}
+	return st.Dev != parent.Dev || st.Ino == parent.Ino
+}
+
+/* Filesystem type of the mountpoint holding path */
+func mountType(path string) string {
+	file, err := os.Open("/proc/mounts")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	var dir, fstype string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// device mountpoint fstype opt1,opt2,...,optn 0 0
+		if len(fields) != 6 {
+			continue
+		}
+		d := strings.Replace(fields[1], "\\040", " ", -1)
+		in := path == d || strings.HasPrefix(path, strings.TrimSuffix(d, "/")+"/")
+		if in && len(d) >= len(dir) { // the deepest mountpoint wins
+			dir, fstype = d, fields[2]
+		}
+	}
+	return fstype
+}
+
+/* Windows drives are mounted with drvfs under WSL1 and 9p under WSL2 */
+func warnWindowsDrive(sc *s_scan) {
+	if !sc.wsl && !sc.wsl2 {
+		return
+	}
+	t := mountType(sc.root)
+	if t != "drvfs" && t != "9p" {
+		return
+	}
+	printAlert(sc, fmt.Sprintf(tr("  [WARNING] Windows drive mounted with %s, stat is very slow here.\n"+
+		"  Running tdu.exe from Windows is much faster.\n"), t))
+}
+
+/* Mountpoints of local block devices, one per device */
+func listMounts(sc *s_scan) []string {
+	var mounts []string
+	file, err := os.Open("/proc/mounts")
+	if err != nil {
+		for _, m := range fsMounts() {
+			if strings.HasPrefix(m.device, "/dev/") {
+				mounts = append(mounts, m.dir)
+			}
+		}
+		return mounts
+	}
+	defer file.Close()
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// device mountpoint fstype opt1,opt2,...,optn 0 0
+		if len(fields) != 6 || !strings.HasPrefix(fields[0], "/dev/") {
+			continue // pseudo and network filesystems
+		}
+		if fields[2] == "squashfs" || seen[fields[0]] {
+			continue // read-only images and bind mounts
+		}
+		seen[fields[0]] = true
+		mounts = append(mounts, strings.Replace(fields[1], "\\040", " ", -1))
+	}
+	return mounts
+}
+
+/* Kernel and memory filesystems, skipped even with --tag-other-fs */
+var pseudoFs = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "binfmtfs": true, "bpf": true,
+	"bpf_fs": true, "cgroup": true, "cgroup2": true, "configfs": true,
+	"debugfs": true, "devfs": true, "devpts": true, "devtmpfs": true,
+	"efivarfs": true, "fdescfs": true, "fusectl": true, "hugetlbfs": true,
+	"kernfs": true, "mqueue": true, "nsfs": true, "proc": true, "procfs": true,
+	"pstore": true, "pstorefs": true, "ptyfs": true, "securityfs": true,
+	"selinux": true, "selinuxfs": true, "sysfs": true, "tmpfs": true,
+	"tracefs": true,
+}
+
+func isPseudoFs(path string) (string, bool) {
+	var st fs_stat
+	if statFs(path, &st) != nil {
+		return "", false
+	}
+	t := mountFsType(path, &st)
+	return t, pseudoFs[t]
+}
+
+/* Type of a mounted filesystem, from /proc/mounts, getfsstat or statfs */
+func mountFsType(dir string, st *fs_stat) string {
+	if t := mountType(dir); t != "" {
+		return t
+	}
+	for _, m := range fsMounts() {
+		if m.dir == dir {
+			return m.fsType
+		}
+	}
+	if t, ok := fsType[st.Type]; ok {
+		return t
+	}
+	return fmt.Sprintf("0x%04X", st.Type)
+}
+
+/* Overview of the local filesystems, like df (--mounts) */
+func showMountList(sc *s_scan) {
+	mounts := listMounts(sc)
+	if len(mounts) == 0 {
+		fmt.Fprintln(sc.out, tr("  [ERROR] No local mountpoint found"))
+		return
+	}
+	fmt.Fprintln(sc.out, header("MOUNTED FILESYSTEMS"))
+	if !sc.plain {
+		fmt.Fprint(sc.out, tr("          Size|        Used|       Avail| Use%|IUse%| Type     Mountpoint\n"))
+	}
+	for _, m := range mounts {
+		var st fs_stat
+		if statFs(m, &st) != nil || st.Blocks == 0 {
+			continue
+		}
+		total := st.Blocks * st.Bsize
+		avail := st.Bavail * st.Bsize
+		used := total - st.Bfree*st.Bsize
+		iused := st.Files - st.Ffree
+		t := mountFsType(m, &st)
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%d\t%d\t%d\t%s\t%s\n", total, used, avail,
+				iused, st.Files, t, m)
+			continue
+		}
+		pct := used * 100 / total
+		if used+avail > 0 { // as df, blocks reserved to root are not counted
+			pct = (used*100 + used + avail - 1) / (used + avail)
+		}
+		var ipct uint64
+		if st.Files > 0 {
+			ipct = iused * 100 / st.Files
+		}
+		fmt.Fprintf(sc.out, "%14s|%12s|%12s|%4d%%|%4d%%| %-8s %s\n", fmtSz(sc, int64(total)),
+			fmtSz(sc, int64(used)), fmtSz(sc, int64(avail)), pct, ipct, t, m)
+	}
+}
+
+/* Mounted filesystem of a device number, without /proc */
+func mountOf(dev uint64) (mount_entry, bool) {
+	for _, m := range fsMounts() {
+		fi, err := os.Lstat(m.dir)
+		if err != nil {
+			continue
+		}
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok && uint64(st.Dev) == dev {
+			return m, true
+		}
+	}
+	return mount_entry{}, false
+}
+
 /* On Linux, try to find the partition name from the device number */
 func getPartition(sc *s_scan, dev uint64) string {
 	if sc.wsl {
 		return fmt.Sprintf("Microsoft WSL [dev 0x%04X]", dev)
 	}
+	if m, ok := mountOf(dev); ok {
+		if dev == sc.currentDevice {
+			sc.partition = m.device
+			sc.partinfo = true
+		}
+		return m.device
+	}
 	name := fmt.Sprintf("[dev 0x%04X]", dev)
 	file, err := os.Open("/proc/partitions")
 	if err != nil { // [Denied]
@@ -344,52 +589,177 @@ func getPartition(sc *s_scan, dev uint64) string {
 }
 
 func partInfo(sc *s_scan) {
+	out := sc.out
+	defer func() { sc.out = out }()
+	sc.out = sc.info // the banner is not part of the report
+	// free space and inodes change all the time (--deterministic)
+	if sc.quiet || sc.deterministic {
+		sc.out = ioutil.Discard // the usage log is still written
+	}
 	p := getPartition(sc, sc.currentDevice)
-	fmt.Printf("  Partition: %s", p)
+	fmt.Fprintf(sc.out, tr("  Partition: %s"), p)
 	if sc.wsl {
-		fmt.Println()
+		fmt.Fprintln(sc.out)
+		warnWindowsDrive(sc)
 		return
 	}
-	var statfs syscall.Statfs_t
+	var statfs fs_stat
 	var total, avail, used uint64
 	wd, _ := os.Getwd()
-	syscall.Statfs(wd, &statfs)
+	statFs(wd, &statfs)
 	if scanMount(sc) {
-		fmt.Printf(" %s %s\n", sc.fsType, sc.mountOptions)
+		fmt.Fprintf(sc.out, " %s %s\n", sc.fsType, sc.mountOptions)
+	} else if m, ok := mountOf(sc.currentDevice); ok {
+		fmt.Fprintf(sc.out, " %s %s\n", m.fsType, m.dir)
 	} else {
-		t, ok := fsType[int64(statfs.Type)]
+		t, ok := fsType[statfs.Type]
 		if !ok {
-			fmt.Printf(" Unknown FS Type 0x%04X", statfs.Type)
+			fmt.Fprintf(sc.out, tr(" Unknown FS Type 0x%04X"), statfs.Type)
 		} else {
-			fmt.Printf(" Type:%s", t)
+			fmt.Fprintf(sc.out, " Type:%s", t)
 		}
-		m := readFlags(int64(statfs.Flags))
-		fmt.Printf(" MFlags:%04X %s\n", statfs.Flags, m)
+		m := readFlags(statfs.Flags)
+		fmt.Fprintf(sc.out, " MFlags:%04X %s\n", statfs.Flags, m)
 	}
 	total = statfs.Files
+	sc.inodeTotal = total
 	if total > 0 {
-		avail = uint64(statfs.Ffree)
+		avail = statfs.Ffree
 		used = total - avail
-		fmt.Printf("  Inodes  :%10d used (%2d%%) of %10d. Avail:%10d\n",
+		fmt.Fprintf(sc.out, tr("  Inodes  :%10d used (%2d%%) of %10d. Avail:%10d\n"),
 			used, used*100/total, total, avail)
+		if fsRoot(sc) { // every used inode will be scanned
+			sc.expectItems = int64(used)
+		}
 	}
-	total = statfs.Blocks * uint64(statfs.Bsize)
+	total = statfs.Blocks * statfs.Bsize
 	if total > 0 {
-		avail = uint64(statfs.Bavail) * uint64(statfs.Bsize)
+		avail = statfs.Bavail * statfs.Bsize
 		used = total - avail
-		if !sc.humanReadable {
-			total /= 1024
-			avail /= 1024
-			used /= 1024
-			fmt.Printf("  Size(kb):%10d used (%2d%%) of %10d. Avail:%10d\n",
-				used, used*100/total, int64(total), int64(avail))
+		sc.partSize, sc.partAvail = int64(total), int64(avail)
+		sc.partFree = int64(statfs.Bfree * statfs.Bsize)
+		if !sc.humanReadable || sc.unit > 0 {
+			base, units := sizeUnits(sc.si)
+			k, label := uint64(base), units[0]
+			if sc.unit > 0 {
+				k, label = uint64(sc.unit), sc.unitLabel
+			}
+			fmt.Fprintf(sc.out, tr("  Size(%s):%9d used (%2d%%) of %10d. Avail:%10d\n"),
+				label, used/k, used*100/total, int64(total/k),
+				int64(avail/k))
 		} else {
-			fmt.Printf("  Size    :%10s used (%2d%%) of %10s. Avail:%10s\n",
+			fmt.Fprintf(sc.out, tr("  Size    :%10s used (%2d%%) of %10s. Avail:%10s\n"),
 				fmtSz(sc, int64(used)), used*100/total,
 				fmtSz(sc, int64(total)), fmtSz(sc, int64(avail)))
 		}
+		projectUsage(sc, used, avail)
+	}
+	showQuotas(sc)
+	warnWindowsDrive(sc)
+	fmt.Fprintln(sc.out)
+}
+
+type quota_usage struct { // Disk quota of the user or of their group
+	name  string // user or group name
+	group bool
+	used  int64
+	limit int64 // the lowest of the soft and hard limits
+}
+
+/* With quotas, the free space of the partition is often not what is
+ * left to the current user */
+func showQuotas(sc *s_scan) {
+	for _, q := range getQuotas(sc.partition) {
+		avail := q.limit - q.used
+		if avail < 0 {
+			avail = 0
+		}
+		m := tr("  Quota   :%10s used (%2d%%) of %10s. Avail:%10s (user %s)\n")
+		if q.group {
+			m = tr("  Quota   :%10s used (%2d%%) of %10s. Avail:%10s (group %s)\n")
+		}
+		fmt.Fprintf(sc.out, m, fmtSz(sc, q.used), q.used*100/q.limit,
+			fmtSz(sc, q.limit), fmtSz(sc, avail), q.name)
+	}
+}
+
+/* Locks are advisory, stat never fails because of them */
+func isLocked(errno syscall.Errno) bool {
+	return false
+}
+
+/* Stale NFS handles and timeouts of network filesystems may disappear
+ * when retrying. EIO is not retried: on a local disk, it is a bad sector
+ * that would stall the scan at each retry. */
+func isTransient(errno syscall.Errno) bool {
+	switch errno {
+	case syscall.ESTALE, syscall.EAGAIN, syscall.ETIMEDOUT:
+		return true
+	}
+	return false
+}
+
+// Entries are read and stat'ed relative to an open handle of their
+// directory (openat, fstatat), whatever the depth of the tree
+const dir_HANDLES = true
+
+/* Each level of the tree keeps its handle open while its content is
+ * scanned: deeper directories are read by path, so that half of the
+ * descriptors allowed to the process are left for the rest. */
+func maxDirHandles() int64 {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return 64
+	}
+	n := int64(rl.Cur) / 2
+	if n <= 0 { // RLIM_INFINITY
+		return 1 << 20
 	}
-	fmt.Println()
+	return n
+}
+
+// Paths have no length limit on this OS
+func longPath(path string) string {
+	return path
+}
+
+/* Owner and group of a scanned item */
+func fileOwner(f *file) (uint32, uint32, bool) {
+	if f.fi == nil {
+		return 0, 0, false
+	}
+	st, ok := f.fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint32(st.Uid), uint32(st.Gid), true
+}
+
+/* Size, used and available bytes of the filesystem holding path */
+func fsStat(path string) (uint64, uint64, uint64, bool) {
+	var statfs fs_stat
+	if err := statFs(path, &statfs); err != nil {
+		return 0, 0, 0, false
+	}
+	total := statfs.Blocks * statfs.Bsize
+	free := statfs.Bfree * statfs.Bsize
+	avail := statfs.Bavail * statfs.Bsize
+	return total, total - free, avail, true
+}
+
+/* Btrfs subvolumes share the device id of their parent, but the root
+ * directory of each subvolume always has the inode number 256. */
+func isSubvolume(f *file) bool {
+	const btrfs_MAGIC = 0x9123683e
+	const btrfs_SUBVOL_INODE = 256
+	if !f.isDir || f.depth == 1 || f.inode != btrfs_SUBVOL_INODE {
+		return false
+	}
+	var statfs fs_stat
+	if err := statFs(f.path, &statfs); err != nil {
+		return false
+	}
+	return statfs.Type == btrfs_MAGIC
 }
 
 func sysStat(sc *s_scan, f *file) error {
@@ -407,25 +777,40 @@ func sysStat(sc *s_scan, f *file) error {
 	f.blockSize = int64(stat.Blksize)
 	f.nBlocks512 = stat.Blocks
 	f.diskUsage = 512 * f.nBlocks512
+	f.isSparse = f.fi.Mode().IsRegular() && isSparse(f.size, f.diskUsage)
 	if f.depth == 1 {
 		sc.currentDevice = f.deviceId
-		partInfo(sc)
+		if _, ok := sc.fs.(os_fs); ok { // a fake tree has no partition
+			partInfo(sc)
+		}
 	}
 	if f.deviceId != sc.currentDevice {
-		f.isOtherFs = true
 		sc.foundBoundary = true
-		m := fmt.Sprintf("  Not crossing FS boundary at %-15s %s",
-			f.fullpath, getPartition(sc, f.deviceId))
-		push(sc, m)
-	}
-	_, ok = sc.inodes[f.inode]
-	if ok { // Hardlink means inode used more than once in map
-		if !f.isOtherFs { // Other FS may have a same inode number (root=2)
-			f.diskUsage = 0
-			sc.nHardlinks++
+		m := tr("  Not crossing FS boundary at %-15s %s")
+		t, pseudo := "", false
+		if sc.tagOtherFs && !sc.includePseudo && f.deviceId != sc.parentDevice {
+			t, pseudo = isPseudoFs(f.fullpath)
+		}
+		if pseudo {
+			f.isOtherFs = true
+			m = tr("  Skipping pseudo filesystem at %-15s %s")
+		} else if sc.tagOtherFs {
+			f.isTagged = true
+			m = tr("  Crossing FS boundary at %-15s %s")
+		} else {
+			f.isOtherFs = true
 		}
+		if pseudo {
+			push(sc, fmt.Sprintf(m, f.fullpath, t))
+		} else if f.deviceId != sc.parentDevice {
+			push(sc, fmt.Sprintf(m, f.fullpath, getPartition(sc, f.deviceId)))
+		}
+	}
+	if sc.subvolumes && isSubvolume(f) {
+		f.isOtherFs = true
+		sc.foundBoundary = true
+		push(sc, fmt.Sprintf(tr("  Not crossing btrfs subvolume at %s"), f.fullpath))
 	}
-	// Each occurrence of inode is counted
-	sc.inodes[f.inode]++
+	countHardlink(sc, f)
 	return nil
 }
@@ -0,0 +1,76 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Virtual machine disk images are usually sparse or thin provisioned:
+ * their virtual size differs from the space allocated on disk.  The
+ * header of the most common formats is read to find the virtual size.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func readAt(fd *os.File, off int64, n int) []byte {
+	b := make([]byte, n)
+	if _, err := fd.ReadAt(b, off); err != nil {
+		return nil
+	}
+	return b
+}
+
+/* Returns the image format and its virtual size in bytes */
+func vmImage(f *file) (string, int64, bool) {
+	if !f.isRegular || f.size < 512 {
+		return "", 0, false
+	}
+	fd, err := os.Open(f.path)
+	if err != nil {
+		return "", 0, false
+	}
+	defer fd.Close()
+	h := readAt(fd, 0, 512)
+	if h == nil {
+		return "", 0, false
+	}
+	switch {
+	case bytes.Equal(h[0:4], []byte{'Q', 'F', 'I', 0xfb}):
+		return "qcow2", int64(binary.BigEndian.Uint64(h[24:32])), true
+	case bytes.Equal(h[0:4], []byte("KDMV")): // sparse extent, in sectors
+		return "vmdk", 512 * int64(binary.LittleEndian.Uint64(h[12:20])), true
+	case binary.LittleEndian.Uint32(h[0x40:0x44]) == 0xbeda107f:
+		if b := readAt(fd, 0x170, 8); b != nil {
+			return "vdi", int64(binary.LittleEndian.Uint64(b)), true
+		}
+	}
+	footer := readAt(fd, f.size-512, 512) // Virtual PC disk
+	if footer != nil && bytes.Equal(footer[0:8], []byte("conectix")) {
+		return "vhd", int64(binary.BigEndian.Uint64(footer[48:56])), true
+	}
+	ext := strings.ToLower(filepath.Ext(f.name))
+	if (ext == ".img" || ext == ".raw") && f.diskUsage < f.size {
+		return "raw", f.size, true
+	}
+	return "", 0, false
+}
+
+func vmImageInfo(sc *s_scan, f *file) string {
+	format, virtual, ok := vmImage(f)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" [%s: %s virtual]", format, fmtSz(sc, virtual))
+}
@@ -0,0 +1,280 @@
+// +build linux
+
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Watch mode (--watch): the scan watches each directory it reads and
+ * records the usage it accounts for each item, then inotify events keep
+ * the totals up to date, and the table is printed again every few
+ * seconds. Inotify is not recursive, so each directory is watched. */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const watch_MASK = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ATTRIB
+
+type watch_usage struct {
+	du   int64
+	size int64
+	key  ino_key // of a file with several links
+	dup  bool    // whose data is counted at another link
+}
+
+type watch_event struct {
+	wd   int32
+	mask uint32
+	name string
+}
+
+type watcher struct {
+	fd      int
+	inodes  ino_map                // links seen by the scan, see countHardlink
+	dirs    map[int32]string       // watch descriptor to directory
+	watched map[string]int32       // directory to watch descriptor
+	sizes   map[string]watch_usage // own usage of each item, as scan() accounted it
+	dirty   map[string]bool        // items changed since the last refresh
+	nFailed int                    // directories that could not be watched
+}
+
+func newWatcher(sc *s_scan) scan_watcher {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		fmt.Fprintf(sc.out, tr("  [ERROR] Cannot watch: %v\n"), err)
+		return nil
+	}
+	return &watcher{fd: fd, inodes: sc.inodes, dirs: make(map[int32]string),
+		watched: make(map[string]int32), sizes: make(map[string]watch_usage),
+		dirty: make(map[string]bool)}
+}
+
+func (w *watcher) watchDir(path string) {
+	wd, err := syscall.InotifyAddWatch(w.fd, path, watch_MASK|syscall.IN_ONLYDIR)
+	if err != nil {
+		w.nFailed++
+		return
+	}
+	w.dirs[int32(wd)] = path
+	w.watched[path] = int32(wd)
+}
+
+func (w *watcher) watchItem(path string, f *file) {
+	u := watch_usage{du: f.diskUsage, size: f.size}
+	if !f.isDir && !f.isOtherFs && f.nLinks > 1 { // see countHardlink
+		u.key = ino_key{f.deviceId, f.inode}
+		u.dup = w.inodes[u.key] > 1
+	}
+	w.sizes[path] = u
+}
+
+/* Scans a new item the way the scan did, with its excludes and the links
+ * it has seen, then adds its usage */
+func (w *watcher) add(sc *s_scan, fi *[]file, t *file, rel string, i os.FileInfo) {
+	if excluded(sc, rel, fs.FileInfoToDirEntry(i)) {
+		return
+	}
+	depth := int64(strings.Count(rel, "/")) + 2
+	f, err := scan(sc, nil, rel, depth, nil, i)
+	if err != nil {
+		return
+	}
+	apply(fi, t, rel, f.diskUsage, f.size, f.items+1)
+	if depth > 2 && depth-1 > sc.maxDepth { // see the kids in scan()
+		return
+	}
+	if list := listOf(fi, filepath.Dir(rel)); list != nil {
+		*list = append(*list, *f)
+		sortFiles(sc, *list)
+	}
+}
+
+/* The depth 1 items, or the kids of the directory dir when kept */
+func listOf(list *[]file, dir string) *[]file {
+	if dir == "." {
+		return list
+	}
+	for i := range *list {
+		f := &(*list)[i]
+		if f.path == dir {
+			return &f.kids
+		}
+		if strings.HasPrefix(dir, f.path+"/") {
+			return listOf(&f.kids, dir)
+		}
+	}
+	return nil
+}
+
+/* Forgets rel and everything below it. The data of a removed link is
+ * then counted at another link of the same file, if one is left. */
+func (w *watcher) remove(fi *[]file, t *file, rel string) {
+	sub := rel + "/"
+	var links []ino_key
+	for p, u := range w.sizes {
+		if p == rel || strings.HasPrefix(p, sub) {
+			delete(w.sizes, p)
+			apply(fi, t, p, -u.du, -u.size, -1)
+			if u.key != (ino_key{}) {
+				w.inodes[u.key]--
+				if !u.dup {
+					links = append(links, u.key)
+				}
+			}
+		}
+	}
+	for _, k := range links {
+		for p, u := range w.sizes {
+			if u.key != k {
+				continue
+			}
+			if i, err := os.Lstat(p); err == nil {
+				if st, ok := i.Sys().(*syscall.Stat_t); ok {
+					u.du, u.dup = 512*st.Blocks, false
+					w.sizes[p] = u
+					apply(fi, t, p, u.du, 0, 0)
+				}
+			}
+			break
+		}
+	}
+	for p, wd := range w.watched {
+		if p == rel || strings.HasPrefix(p, sub) {
+			delete(w.watched, p)
+			delete(w.dirs, wd)
+		}
+	}
+}
+
+/* Applies the changes of the items modified since the last refresh.
+ * Removed items go first, as a directory moved within the tree keeps its
+ * watch descriptor, and a new directory is scanned before its entries
+ * are seen as new items. */
+func (w *watcher) refresh(sc *s_scan, fi *[]file, t *file) {
+	var paths []string
+	for p := range w.dirty {
+		if _, known := w.sizes[p]; !known {
+			paths = append(paths, p)
+		} else if _, err := os.Lstat(p); err != nil {
+			w.remove(fi, t, p)
+		} else {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		old, known := w.sizes[p]
+		i, err := os.Lstat(p)
+		switch {
+		case err != nil:
+		case !known:
+			w.add(sc, fi, t, p, i)
+		default:
+			st, ok := i.Sys().(*syscall.Stat_t)
+			if !ok {
+				continue
+			}
+			u := old
+			u.du, u.size = 512*st.Blocks, i.Size()
+			if u.dup {
+				u.du = 0
+			}
+			w.sizes[p] = u
+			apply(fi, t, p, u.du-old.du, u.size-old.size, 0)
+		}
+	}
+	w.dirty = make(map[string]bool)
+}
+
+func readEvents(fd int, events chan<- watch_event) {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := syscall.Read(fd, buf)
+		if err != nil || n <= 0 {
+			close(events)
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			off += syscall.SizeofInotifyEvent
+			name := ""
+			if ev.Len > 0 {
+				name = strings.TrimRight(string(buf[off:off+int(ev.Len)]), "\x00")
+				off += int(ev.Len)
+			}
+			events <- watch_event{ev.Wd, ev.Mask, name}
+		}
+	}
+}
+
+func watch(sc *s_scan, fi *[]file, t *file) {
+	w := sc.watcher.(*watcher)
+	defer syscall.Close(w.fd)
+	// the exports are complete: new items only update the table, and are
+	// scanned in full
+	sc.export, sc.jsonl, sc.savePath, sc.manifestPath = false, false, "", ""
+	sc.ctx, sc.maxItems, sc.maxBytes = context.Background(), 0, 0
+	if w.nFailed > 0 {
+		printAlert(sc, fmt.Sprintf(tr("  [WARNING] %d directories not watched (see fs.inotify.max_user_watches)\n"), w.nFailed))
+	}
+	events := make(chan watch_event, 256)
+	go readEvents(w.fd, events)
+	tick := time.NewTicker(cst_WATCHBEAT * time.Millisecond)
+	defer tick.Stop()
+	lost := false
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if ev.mask&syscall.IN_Q_OVERFLOW != 0 {
+				lost = true
+				continue
+			}
+			d, ok := w.dirs[ev.wd]
+			if !ok || ev.name == "" {
+				continue
+			}
+			w.dirty[d] = true // its own blocks may grow or shrink
+			if d == "." {
+				w.dirty[ev.name] = true
+			} else {
+				w.dirty[d+"/"+ev.name] = true
+			}
+		case <-tick.C:
+			if len(w.dirty) == 0 && !lost {
+				continue
+			}
+			w.refresh(sc, fi, t)
+			if sc.tty {
+				cls(sc)
+			}
+			fmt.Fprintf(sc.out, tr("  Watching [%s] at %s, press Ctrl+C to stop.\n\n"),
+				sc.root, time.Now().Format("15:04:05"))
+			if lost {
+				printAlert(sc, tr("  [WARNING] Some events were lost, totals may be inaccurate.\n\n"))
+				lost = false
+			}
+			show(sc, *fi, t)
+		}
+	}
+}
@@ -40,6 +40,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
@@ -91,6 +92,7 @@ type (
 		mi          monitor
 		max         coord
 		zero        coord
+		clusters    map[string]int64 // cluster size of each volume
 	}
 	coord struct {
 		x int16
@@ -128,12 +130,19 @@ const (
 	kGetConsoleMode               = "GetConsoleMode"
 	kGetConsoleScreenBufferInfo   = "GetConsoleScreenBufferInfo"
 	kGetConsoleWindow             = "GetConsoleWindow"
+	kGetCompressedFileSizeW       = "GetCompressedFileSizeW"
 	kGetCurrentConsoleFont        = "GetCurrentConsoleFont"
+	kGetDiskFreeSpaceW            = "GetDiskFreeSpaceW"
+	kGetDiskFreeSpaceExW          = "GetDiskFreeSpaceExW"
+	kGetDriveTypeW                = "GetDriveTypeW"
+	kGetLogicalDrives             = "GetLogicalDrives"
+	kGetVolumeInformationW        = "GetVolumeInformationW"
 	kGetFileType                  = "GetFileType"
 	kGetStdHandle                 = "GetStdHandle"
 	kSetConsoleCursorPosition     = "SetConsoleCursorPosition"
 	kSetConsoleMode               = "SetConsoleMode"
 	kSetConsoleScreenBufferSize   = "SetConsoleScreenBufferSize"
+	kSetConsoleTextAttribute      = "SetConsoleTextAttribute"
 	kSetConsoleTitleA             = "SetConsoleTitleA"
 	kSetConsoleWindowInfo         = "SetConsoleWindowInfo"
 	kCloseHandle                  = "CloseHandle"
@@ -158,6 +167,14 @@ const (
 	background_intensity = 0x0080 // Background color is intensified.
 )
 
+const ( // Growth markers in the table (no Unicode in cmd.exe)
+	arrow_UP   = "+"
+	arrow_DOWN = "-"
+	bar_CHAR   = "#" // bar charts
+	bar_FULL   = "#" // bars of the main table
+	bar_EMPTY  = "."
+)
+
 func dyncall(addr uintptr, a []uintptr) (r1, r2 uintptr, lastErr error) {
 	l := len(a)
 	s3 := syscall.Syscall
@@ -177,6 +194,9 @@ func dyncall(addr uintptr, a []uintptr) (r1, r2 uintptr, lastErr error) {
 		return s6(addr, uintptr(l), a[0], a[1], a[2], a[3], a[4], 0)
 	case 6:
 		return s6(addr, uintptr(l), a[0], a[1], a[2], a[3], a[4], a[5])
+	case 7, 8, 9:
+		a = append(a, 0, 0)
+		return syscall.Syscall9(addr, uintptr(l), a[0], a[1], a[2], a[3], a[4], a[5], a[6], a[7], a[8])
 	default:
 		panic("dyncall with too many arguments")
 	}
@@ -187,6 +207,7 @@ func createWin32() *win32 {
 	w.zero = coord{0, 0}
 	w.ttyWidth = 80
 	w.fromCmdLine = false
+	w.clusters = make(map[string]int64)
 	return &w
 }
 
@@ -235,12 +256,19 @@ func (w *win32) populate() {
 		kGetConsoleMode,
 		kGetConsoleScreenBufferInfo,
 		kGetConsoleWindow,
+		kGetCompressedFileSizeW,
 		kGetCurrentConsoleFont,
+		kGetDiskFreeSpaceW,
+		kGetDiskFreeSpaceExW,
+		kGetDriveTypeW,
+		kGetLogicalDrives,
+		kGetVolumeInformationW,
 		kGetFileType,
 		kGetStdHandle,
 		kSetConsoleCursorPosition,
 		kSetConsoleMode,
 		kSetConsoleScreenBufferSize,
+		kSetConsoleTextAttribute,
 		kSetConsoleTitleA,
 		kSetConsoleWindowInfo,
 		kCloseHandle,
@@ -501,6 +529,14 @@ func (w *win32) setConsoleScreenBufferSize(h uintptr, size coord) (bool, uintptr
 	return w.call(f, h, size.uintptr())
 }
 
+func (w *win32) setConsoleTextAttribute(attr uint16) (bool, uintptr) {
+	f := kSetConsoleTextAttribute
+	if w.hOutput == 0 {
+		panic(f)
+	}
+	return w.call(f, w.hOutput, uintptr(attr))
+}
+
 func (w *win32) maximizeWindow(h uintptr) (bool, uintptr) {
 	m := 3 // Maximize window
 	f := uShowWindow
@@ -560,7 +596,7 @@ func (w *win32) pressAnyKey(msg string) bool {
 	}
 	defer w.setConsoleMode(w.hInput, m)
 	fmt.Println()
-	fmt.Printf(msg)
+	fmt.Print(msg)
 	bin := make([]byte, 10)
 	if _, err := os.Stdin.Read(bin); err != nil {
 		panic(err)
@@ -586,19 +622,56 @@ func osEnd(sys interface{}) bool {
 
 func getTtyWidth(sc *s_scan) int {
 	w := sc.sys.(*win32)
+	if !sc.tty {
+		return w.ttyWidth
+	}
+	var info scrbuf
+	if b, _ := w.getConsoleScreenBufferInfo(&info); b && info.size.x > 0 {
+		return int(info.size.x) // lines wrap at the width of the buffer
+	}
 	return w.ttyWidth
 }
 
+// The console has no resize signal, its width is polled instead
+func watchResize(sc *s_scan) {
+	if !sc.tty {
+		return
+	}
+	go func() {
+		n := getTtyWidth(sc)
+		for {
+			time.Sleep(time.Second)
+			m := getTtyWidth(sc)
+			if m == n {
+				continue
+			}
+			n = m
+			select {
+			case sc.resize <- true:
+			default: // a resize is already pending
+			}
+		}
+	}()
+}
+
 func initTty(sc *s_scan) {
 	w := sc.sys.(*win32)
+	sc.info = sc.out
 	sc.tty = !w.isRemoteSession()
 	if !sc.tty {
-		fmt.Println("  Detected Remote Session.")
+		if !sc.quiet {
+			fmt.Fprintln(sc.out, "  Detected Remote Session.")
+		}
 		return
 	}
 	sc.tty = w.setIO()
 	if !sc.tty {
-		fmt.Fprintln(os.Stderr, "  Not in Console output mode (redirected).")
+		if sc.con == os.Stdout { // tdu > report.txt: only the report in the file
+			sc.info = os.Stderr
+		}
+		if !sc.quiet {
+			fmt.Fprintln(os.Stderr, "  Not in Console output mode (redirected).")
+		}
 		return
 	}
 	m := fmt.Sprintf("Top Disk Usage v%s (GNU GPL)", prg_VERSION)
@@ -626,11 +699,12 @@ func initTty(sc *s_scan) {
 		}
 	}
 	if !w.fromCmdLine {
-		fmt.Println()
-		fmt.Println("  This program should be run from the command line.")
+		fmt.Fprintln(sc.out)
+		fmt.Fprintln(sc.out, "  This program should be run from the command line.")
 		w.pressAnyKey("  Press any key to continue...")
 	}
 	sc.tty = w.updateConsole(sc)
+	sc.progress = sc.tty
 	sc.refreshDelay *= 3
 }
 
@@ -708,19 +782,45 @@ func printAlert(sc *s_scan, msg string) {
 	c = foreground_red
 	if sc.tty {
 		w.writeColored(c|foreground_intensity, msg)
+		if sc.report != nil {
+			fmt.Fprint(sc.report, msg)
+		}
 	} else {
-		fmt.Printf(msg)
+		fmt.Fprint(sc.out, msg)
 	}
 }
 
+func printShare(sc *s_scan, level int, msg string) {
+	var c uint16
+	w := sc.sys.(*win32)
+	switch level {
+	case share_HIGH:
+		c = foreground_red
+	case share_MID:
+		c = foreground_red | foreground_green
+	default:
+		fmt.Fprint(sc.out, msg)
+		return
+	}
+	var info scrbuf
+	b, _ := w.getConsoleScreenBufferInfo(&info)
+	if !b {
+		fmt.Fprint(sc.out, msg)
+		return
+	}
+	w.setConsoleTextAttribute(c | foreground_intensity)
+	fmt.Fprint(sc.out, msg)
+	w.setConsoleTextAttribute(info.attr)
+}
+
 func printProgress(sc *s_scan) {
 	var c uint16
 	w := sc.sys.(*win32)
-	if !sc.tty {
+	if !sc.progress {
 		return
 	}
 	n := sc.nErrors + sc.nItems
-	m := fmt.Sprintf("  [.... scanning... %6d  ....]", n)
+	m := fmt.Sprintf(tr("  [.... scanning... ")+"%6d%s", n, progressInfo(sc))
 	if sc.nErrors > 0 {
 		c = foreground_red | foreground_green
 	} else {
@@ -729,13 +829,251 @@ func printProgress(sc *s_scan) {
 	w.writeColored(c|foreground_intensity, m)
 }
 
-// Disk usage is inaccurate because appropriate syscall is not yet implemented
+func getPartition(sc *s_scan, dev uint64) string {
+	return fmt.Sprintf("[dev 0x%04X]", dev)
+}
+
+// Root of each fixed drive (C:\, D:\...), skipping removable, network
+// and optical drives
+func listMounts(sc *s_scan) []string {
+	const drive_fixed = 3
+	w := sc.sys.(*win32)
+	var drives []string
+	mask, _, _ := dyncall(w.procs[w.find(kGetLogicalDrives)].fx.Addr(), nil)
+	for i := 0; i < 26; i++ {
+		if mask&(1<<uint(i)) == 0 {
+			continue
+		}
+		root := string(rune('A'+i)) + `:\`
+		p, err := syscall.UTF16PtrFromString(root)
+		if err != nil {
+			continue
+		}
+		t, _, _ := dyncall(w.procs[w.find(kGetDriveTypeW)].fx.Addr(), []uintptr{
+			uintptr(unsafe.Pointer(p))})
+		if t == drive_fixed {
+			drives = append(drives, root)
+		}
+	}
+	return drives
+}
+
+// Size and free space of a drive, with its label and filesystem
+func (w *win32) volumeInfo(root string) (total, free int64, label, fsType string, ok bool) {
+	p, err := syscall.UTF16PtrFromString(root)
+	if err != nil {
+		return 0, 0, "", "", false
+	}
+	var avail, size, totalFree uint64
+	r, _, _ := dyncall(w.procs[w.find(kGetDiskFreeSpaceExW)].fx.Addr(), []uintptr{
+		uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&avail)),
+		uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&totalFree))})
+	if r == 0 {
+		return 0, 0, "", "", false
+	}
+	var name, fs [MAX_PATH + 1]uint16
+	var serial, maxLen, flags uint32
+	r, _, _ = dyncall(w.procs[w.find(kGetVolumeInformationW)].fx.Addr(), []uintptr{
+		uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&name[0])), uintptr(len(name)),
+		uintptr(unsafe.Pointer(&serial)), uintptr(unsafe.Pointer(&maxLen)),
+		uintptr(unsafe.Pointer(&flags)), uintptr(unsafe.Pointer(&fs[0])), uintptr(len(fs))})
+	if r != 0 {
+		label, fsType = syscall.UTF16ToString(name[:]), syscall.UTF16ToString(fs[:])
+	}
+	return int64(size), int64(avail), label, fsType, true
+}
+
+// Files opened without sharing by another process
+func isLocked(errno syscall.Errno) bool {
+	return errno == 32 || errno == 33 // SHARING_VIOLATION, LOCK_VIOLATION
+}
+
+// Network share errors that may disappear when retrying
+func isTransient(errno syscall.Errno) bool {
+	switch errno {
+	case 53, 59, 64, 121: // BAD_NETPATH, UNEXP_NET_ERR, NETNAME_DELETED, SEM_TIMEOUT
+		return true
+	}
+	return false
+}
+
+// Extended attributes are not read on this OS
+func getXattr(path, name string) (string, bool) {
+	return "", false
+}
+
+func xattrSize(path string) int64 {
+	return 0
+}
+
+// Owners are security identifiers on Windows, not numeric ids
+func fileOwner(f *file) (uint32, uint32, bool) {
+	return 0, 0, false
+}
+
+// Filesystem boundaries are not detected on this OS
+func fsStat(path string) (uint64, uint64, uint64, bool) {
+	return 0, 0, 0, false
+}
+
+const dir_HANDLES = false // long paths are handled by longPath()
+
+func maxDirHandles() int64 {
+	return 0
+}
+
+var statusSignals []os.Signal // no SIGUSR1 nor SIGINFO
+
+/* Paths beyond MAX_PATH only work with the extended-length prefix,
+ * which disables normalization and thus requires a clean absolute path. */
+func longPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	p, err := filepath.Abs(path)
+	if err != nil || len(p) < MAX_PATH-12 { // 12: room for a 8.3 name
+		return path
+	}
+	if strings.HasPrefix(p, `\\`) {
+		return `\\?\UNC\` + p[2:]
+	}
+	return `\\?\` + p
+}
+
+// Cluster size of the volume holding path, cached per volume
+func (w *win32) clusterSize(path string) int64 {
+	vol := filepath.VolumeName(path) + `\`
+	if c, ok := w.clusters[vol]; ok {
+		return c
+	}
+	c := int64(4096)
+	p, err := syscall.UTF16PtrFromString(vol)
+	if err == nil {
+		var spc, bps, free, total uint32
+		i := w.find(kGetDiskFreeSpaceW)
+		r, _, _ := dyncall(w.procs[i].fx.Addr(), []uintptr{
+			uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&spc)),
+			uintptr(unsafe.Pointer(&bps)), uintptr(unsafe.Pointer(&free)),
+			uintptr(unsafe.Pointer(&total))})
+		if r != 0 && spc*bps > 0 {
+			c = int64(spc) * int64(bps)
+		}
+	}
+	w.clusters[vol] = c
+	return c
+}
+
+// Size actually stored on disk for compressed and sparse files
+func (w *win32) compressedSize(path string) (int64, bool) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, false
+	}
+	var high uint32
+	i := w.find(kGetCompressedFileSizeW)
+	r, _, e := dyncall(w.procs[i].fx.Addr(), []uintptr{
+		uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&high))})
+	if errno, _ := e.(syscall.Errno); uint32(r) == 0xFFFFFFFF && errno != 0 {
+		return 0, false
+	}
+	return int64(high)<<32 | int64(uint32(r)), true
+}
+
+// Volume serial number, file index and number of links of a file
+func fileIndex(path string) (*syscall.ByHandleFileInformation, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	const share = syscall.FILE_SHARE_READ | syscall.FILE_SHARE_WRITE | syscall.FILE_SHARE_DELETE
+	const flags = syscall.FILE_FLAG_BACKUP_SEMANTICS | syscall.FILE_FLAG_OPEN_REPARSE_POINT
+	h, err := syscall.CreateFile(p, 0, share, nil, syscall.OPEN_EXISTING, flags, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.CloseHandle(h)
+	var d syscall.ByHandleFileInformation
+	if err = syscall.GetFileInformationByHandle(h, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Disk usage is the compressed size rounded up to the cluster size
 func sysStat(sc *s_scan, f *file) error {
+	w := sc.sys.(*win32)
 	f.deviceId = 0
 	f.inode = 0
 	f.nLinks = 0
-	f.blockSize = 4096
-	f.nBlocks512 = 0
+	path := longPath(f.fullpath)
+	f.blockSize = w.clusterSize(f.fullpath)
 	f.diskUsage = f.size
+	if f.isRegular {
+		if n, ok := w.compressedSize(path); ok {
+			f.diskUsage = (n + f.blockSize - 1) / f.blockSize * f.blockSize
+		}
+	}
+	f.nBlocks512 = f.diskUsage / 512
+	if d, err := fileIndex(path); err == nil {
+		f.deviceId = uint64(d.VolumeSerialNumber)
+		f.inode = uint64(d.FileIndexHigh)<<32 | uint64(d.FileIndexLow)
+		f.nLinks = uint64(d.NumberOfLinks)
+		countHardlink(sc, f) // WinSxS and package caches are full of them
+	}
+	return nil
+}
+
+// Filesystem events are only watched on Linux, see usage()
+func newWatcher(sc *s_scan) scan_watcher { return nil }
+
+func watch(sc *s_scan, fi *[]file, t *file) {}
+
+// Directories are not opened in a Root on this OS, see dir_HANDLES
+func dirEntries(d *os.File) ([]os.DirEntry, error) {
+	return d.ReadDir(-1)
+}
+
+// Shared extents are only read on Linux, see --shared
+func fileExtents(path string) (int64, int64, bool) {
+	return 0, 0, false
+}
+
+/* Overview of the fixed drives (--drives or --mounts) */
+func showMountList(sc *s_scan) {
+	w := sc.sys.(*win32)
+	drives := listMounts(sc)
+	if len(drives) == 0 {
+		fmt.Fprintln(sc.out, tr("  [ERROR] No fixed drive found"))
+		return
+	}
+	fmt.Fprintln(sc.out, header("FIXED DRIVES"))
+	if !sc.plain {
+		fmt.Fprint(sc.out, tr("          Size|        Used|        Free| Use%| Type     Drive\n"))
+	}
+	for _, d := range drives {
+		total, free, label, t, ok := w.volumeInfo(d)
+		if !ok || total == 0 {
+			continue
+		}
+		used := total - free
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\t%d\t%s\t%s\t%s\n", total, used, free, t, d, label)
+			continue
+		}
+		if label != "" {
+			label = "[" + label + "]"
+		}
+		fmt.Fprintf(sc.out, "%14s|%12s|%12s|%4d%%| %-8s %s %s\n", fmtSz(sc, total),
+			fmtSz(sc, used), fmtSz(sc, free), used*100/total, t, d, label)
+	}
+	if sc.plain {
+		return
+	}
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, tr("  Scan them all with --all-mounts, or some with: tdu C:\\ D:\\"))
+}
+
+// Bind mounts are only detected on Linux, from /proc/self/mountinfo
+func dupMounts(root string, crossFs bool) map[string]string {
 	return nil
 }
@@ -0,0 +1,69 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Extended attributes are not part of the file size, but they may use
+ * whole blocks of their own (ACLs, security labels, resource forks). */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+type xattr_usage struct {
+	path string
+	size int64
+}
+
+func countXattrs(sc *s_scan, f *file) {
+	if sc.maxXattrs <= 0 {
+		return
+	}
+	n := sc.fs.xattrSize(f.path)
+	if n <= 0 {
+		return
+	}
+	sc.xattrTotal += n
+	if len(sc.xattrs) > sc.maxXattrs*4 {
+		sortXattrs(sc)
+		sc.xattrs = sc.xattrs[0:sc.maxXattrs]
+	}
+	sc.xattrs = append(sc.xattrs, xattr_usage{f.path, n})
+}
+
+func sortXattrs(sc *s_scan) {
+	sort.Slice(sc.xattrs, func(i, j int) bool {
+		return sc.xattrs[i].size > sc.xattrs[j].size
+	})
+}
+
+func showxattrs(sc *s_scan) {
+	if sc.maxXattrs <= 0 || len(sc.xattrs) == 0 {
+		return
+	}
+	sortXattrs(sc)
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("BIGGEST XATTRS"))
+	for i, x := range sc.xattrs {
+		i++
+		if i > sc.maxXattrs {
+			break
+		}
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%s\n", x.size, fullPath(sc, x.path))
+			continue
+		}
+		fmt.Fprintf(sc.out, "%3d.%12s| %s\n", i, fmtSz(sc, x.size), x.path)
+	}
+	if !sc.plain {
+		fmt.Fprintf(sc.out, tr("  =%13s| in all extended attributes\n"), fmtSz(sc, sc.xattrTotal))
+	}
+}
@@ -0,0 +1,59 @@
+/* Top Disk Usage.
+ * Copyright (C) 2019-2021 Joseph Paul <joseph.paul1@gmx.com>
+ * https://github.com/josephpaul0/tdu
+ *
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+
+/* Disk usage per modification year (--by-year) */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const cst_BARWIDTH = 30
+
+func countYear(sc *s_scan, f *file) {
+	if sc.years == nil || f.mtime.IsZero() {
+		return
+	}
+	sc.years[f.mtime.Year()] += f.diskUsage
+}
+
+func showyears(sc *s_scan, total *file) {
+	if len(sc.years) == 0 || total.diskUsage == 0 {
+		return
+	}
+	var years []int
+	var max int64
+	for y, du := range sc.years {
+		years = append(years, y)
+		if du > max {
+			max = du
+		}
+	}
+	sort.Ints(years)
+	fmt.Fprintln(sc.out)
+	fmt.Fprintln(sc.out, header("USAGE BY YEAR"))
+	for _, y := range years {
+		du := sc.years[y]
+		if sc.plain {
+			fmt.Fprintf(sc.out, "%d\t%d\n", du, y)
+			continue
+		}
+		p := float64(du*100.0) / float64(total.diskUsage)
+		n := int(du * cst_BARWIDTH / max)
+		if n == 0 && du > 0 {
+			n = 1
+		}
+		fmt.Fprintf(sc.out, "  %4d|%12s|%6.2f%%| %s\n", y, fmtSz(sc, du), p,
+			strings.Repeat(bar_CHAR, n))
+	}
+}